@@ -0,0 +1,128 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// additions tracks the nodes in update which were added to dest.
+type additions struct {
+	// added contains the update nodes which were added to dest.
+	added map[*yaml.Node]bool
+
+	// elementKeys maps the update associative list elements to their
+	// merge keys, so that the elements can be identified in the document.
+	elementKeys map[*yaml.Node][]string
+}
+
+// recordAddition records that the update value was added to dest.
+func (m Visitor) recordAddition(nodes walk.Sources) {
+	if m.report == nil || m.report.additions == nil || !yaml.IsMissingOrNull(nodes.Dest()) {
+		return
+	}
+	m.report.additions.added[nodes.Updated().YNode()] = true
+}
+
+// recordElementKeys records the merge keys for the update element of an
+// associative list.
+func (m Visitor) recordElementKeys(elements walk.Sources, keys []string) {
+	if m.report == nil || m.report.additions == nil || elements.Updated() == nil {
+		return
+	}
+	m.report.additions.elementKeys[elements.Updated().YNode()] = keys
+}
+
+// document returns a copy of update containing only the added nodes, or nil
+// if nothing was added.
+func (a *additions) document(update *yaml.RNode) *yaml.RNode {
+	if update.IsNil() {
+		return nil
+	}
+	node := a.filter(update.YNode())
+	if node == nil {
+		return nil
+	}
+	return yaml.NewRNode(node)
+}
+
+// filter returns a copy of node containing only the added nodes.
+func (a *additions) filter(node *yaml.Node) *yaml.Node {
+	if a.added[node] {
+		return yaml.NewRNode(node).Copy().YNode()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return a.filter(node.Content[0])
+	case yaml.MappingNode:
+		result := &yaml.Node{Kind: yaml.MappingNode, Tag: node.Tag}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := a.filter(node.Content[i+1])
+			if value == nil {
+				continue
+			}
+			key := *node.Content[i]
+			result.Content = append(result.Content, &key, value)
+		}
+		if len(result.Content) == 0 {
+			return nil
+		}
+		return result
+	case yaml.SequenceNode:
+		result := &yaml.Node{Kind: yaml.SequenceNode, Tag: node.Tag, Style: node.Style}
+		for _, element := range node.Content {
+			value := a.filter(element)
+			if value == nil {
+				continue
+			}
+			a.setElementKeys(element, value)
+			result.Content = append(result.Content, value)
+		}
+		if len(result.Content) == 0 {
+			return nil
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// setElementKeys copies the merge key fields of element onto value so that
+// the element can be identified.
+func (a *additions) setElementKeys(element, value *yaml.Node) {
+	if value.Kind != yaml.MappingNode {
+		return
+	}
+	src, dst := yaml.NewRNode(element), yaml.NewRNode(value)
+	keys := a.elementKeys[element]
+	for i := len(keys) - 1; i >= 0; i-- {
+		key := keys[i]
+		if key == "" || dst.Field(key) != nil {
+			continue
+		}
+		field := src.Field(key)
+		if field == nil {
+			continue
+		}
+		// keep the merge keys first so the element reads naturally
+		k, v := *field.Key.YNode(), *field.Value.YNode()
+		value.Content = append([]*yaml.Node{&k, &v}, value.Content...)
+	}
+}
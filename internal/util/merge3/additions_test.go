@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_PreviewAdditions(t *testing.T) {
+	origin := `
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+  paused: false
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+`
+	update := `
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: nginx
+spec:
+  replicas: 2
+  minReadySeconds: 10
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        imagePullPolicy: Always
+      - name: sidecar
+        image: sidecar:1.0
+`
+	local := `
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+  paused: false
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+`
+
+	_, report, err := Visitor{InferAssociativeLists: true, PreviewAdditions: true}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+metadata:
+  labels:
+    app: nginx
+spec:
+  minReadySeconds: 10
+  template:
+    spec:
+      containers:
+      - name: nginx
+        imagePullPolicy: Always
+      - name: sidecar
+        image: sidecar:1.0
+`), strings.TrimSpace(report.Additions.MustString()))
+
+	// nothing is recorded without the option
+	_, report, err = Visitor{InferAssociativeLists: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Nil(t, report.Additions)
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// CrossDocumentAliasPolicy controls how aliases which reference an anchor
+// in another document of a multi-document stream are handled.  YAML scopes
+// anchors to their document, but some tools write such streams.
+type CrossDocumentAliasPolicy int
+
+const (
+	// CrossDocumentAliasesError fails the merge with an error naming the
+	// alias and the documents.
+	CrossDocumentAliasesError CrossDocumentAliasPolicy = iota
+
+	// CrossDocumentAliasesResolve replaces each cross-document alias with a
+	// copy of the value of its anchor before merging.
+	CrossDocumentAliasesResolve
+)
+
+// resolveCrossDocumentAliases returns the stream with its cross-document
+// aliases handled according to the CrossDocumentAliases policy.  Streams
+// without cross-document aliases are returned unchanged.
+func (r Registry) resolveCrossDocumentAliases(name, stream string) (string, error) {
+	docs := splitDocuments(stream)
+	if len(docs) < 2 || !strings.Contains(stream, "*") {
+		return stream, nil
+	}
+
+	// parse the documents as the elements of a single list, in which
+	// anchors are shared
+	var b strings.Builder
+	for _, doc := range docs {
+		b.WriteString("-\n")
+		for _, line := range strings.Split(doc, "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	var list yaml.Node
+	if err := yaml.Unmarshal([]byte(b.String()), &list); err != nil ||
+		len(list.Content) != 1 || len(list.Content[0].Content) != len(docs) {
+		// let the stream fail to parse as usual
+		return stream, nil
+	}
+	elements := list.Content[0].Content
+
+	anchors := map[*yaml.Node]int{}
+	for i := range elements {
+		indexAnchors(elements[i], i, anchors)
+	}
+	resolved := false
+	for i := range elements {
+		err := resolveAliases(elements[i], i, anchors, func(alias *yaml.Node, doc int) error {
+			if r.CrossDocumentAliases == CrossDocumentAliasesError {
+				return &Error{
+					Kind: ErrorKindCrossDocumentAlias,
+					Message: fmt.Sprintf("document %d of %s references the anchor %q of document %d",
+						i+1, name, alias.Value, doc+1),
+				}
+			}
+			resolved = true
+			value := yaml.NewRNode(alias.Alias).Copy().YNode()
+			value.Anchor = ""
+			*alias = *value
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if !resolved {
+		return stream, nil
+	}
+
+	var out []string
+	for i := range elements {
+		s, err := yaml.NewRNode(elements[i]).String()
+		if err != nil {
+			return "", err
+		}
+		out = append(out, s)
+	}
+	return strings.Join(out, "---\n"), nil
+}
+
+// splitDocuments splits a multi-document stream on its `---` separators.
+func splitDocuments(stream string) []string {
+	var docs []string
+	var doc []string
+	for _, line := range strings.Split(stream, "\n") {
+		if line == "---" || strings.HasPrefix(line, "--- ") {
+			docs = append(docs, strings.Join(doc, "\n"))
+			doc = nil
+			continue
+		}
+		doc = append(doc, line)
+	}
+	docs = append(docs, strings.Join(doc, "\n"))
+
+	// drop empty documents, e.g. before a leading separator
+	var nonEmpty []string
+	for _, d := range docs {
+		if strings.TrimSpace(d) != "" {
+			nonEmpty = append(nonEmpty, d)
+		}
+	}
+	return nonEmpty
+}
+
+// indexAnchors records the document of each anchored node of node.
+func indexAnchors(node *yaml.Node, doc int, anchors map[*yaml.Node]int) {
+	if node.Anchor != "" {
+		anchors[node] = doc
+	}
+	for _, n := range node.Content {
+		indexAnchors(n, doc, anchors)
+	}
+}
+
+// resolveAliases calls fn for each alias of node, in document doc, which
+// references an anchor in another document.  The values fn copies in place
+// of an alias are resolved as well.
+func resolveAliases(node *yaml.Node, doc int, anchors map[*yaml.Node]int, fn func(*yaml.Node, int) error) error {
+	if node.Kind == yaml.AliasNode {
+		if target, found := anchors[node.Alias]; found && target != doc {
+			if err := fn(node, target); err != nil {
+				return err
+			}
+		}
+	}
+	for _, n := range node.Content {
+		if err := resolveAliases(n, doc, anchors, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_CrossDocumentAliases(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data: &data
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data: *data
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data: &data
+  value: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data: *data
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data:
+  value: origin
+  local: value
+`
+
+	// cross-document aliases are an error by default
+	_, _, err := Registry{}.MergeStrings(local, origin, update)
+	assert.Equal(t, &Error{
+		Kind:    ErrorKindCrossDocumentAlias,
+		Message: `document 2 of origin references the anchor "data" of document 1`,
+	}, err)
+
+	// aliases are resolved to copies of their anchor's value
+	actual, _, err := Registry{CrossDocumentAliases: CrossDocumentAliasesResolve}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data:
+  value: update
+  local: value
+`), strings.TrimSpace(actual))
+}
+
+func TestRegistry_CrossDocumentAliases_sameDocument(t *testing.T) {
+	// aliases within a document aren't cross-document aliases
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data: &data
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+labels: &labels
+  app: b
+annotations: *labels
+`
+
+	_, _, err := Registry{}.MergeStrings(origin, origin, origin)
+	assert.NoError(t, err)
+}
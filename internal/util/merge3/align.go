@@ -0,0 +1,100 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// commentColumns returns the column of the line comment on each line of
+// text, indexed by the line as the yaml encoder writes it, with a single
+// space before the comment.  Lines which occur more than once have their
+// columns in order.
+func commentColumns(text string) map[string][]int {
+	columns := map[string][]int{}
+	lines := strings.Split(text, "\n")
+	for _, n := range commentLines(text) {
+		line, i := lines[n.line], n.column
+		key := strings.TrimRight(line[:i], " \t") + " " + line[i:]
+		columns[key] = append(columns[key], i)
+	}
+	return columns
+}
+
+// alignComments moves the line comment on each line of text which is
+// unchanged from dest to its column in dest.
+func alignComments(text string, columns map[string][]int) string {
+	lines := strings.Split(text, "\n")
+	for _, n := range commentLines(text) {
+		line, i := lines[n.line], n.column
+		c := columns[line]
+		if len(c) == 0 {
+			continue
+		}
+		columns[line] = c[1:]
+		if c[0] <= i {
+			continue
+		}
+		lines[n.line] = line[:i] + strings.Repeat(" ", c[0]-i) + line[i:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentLine is the position of a line comment in a document.
+type commentLine struct {
+	// line is the index of the line.
+	line int
+
+	// column is the index of the `#` starting the comment.
+	column int
+}
+
+// commentLines returns the positions of the line comments on the nodes of
+// the document text, in order.  The comments are found by parsing text, so
+// that `#` characters in scalars, e.g. in the content of block scalars, are
+// not mistaken for comments.
+func commentLines(text string) []commentLine {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	found := map[int]int{}
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		if node.LineComment != "" && node.Line > 0 && node.Line <= len(lines) {
+			// the comment ends the line, other than trailing whitespace
+			line := strings.TrimRight(lines[node.Line-1], " \t")
+			if i := len(line) - len(node.LineComment); i > 0 && strings.HasSuffix(line, node.LineComment) &&
+				(line[i-1] == ' ' || line[i-1] == '\t') {
+				found[node.Line-1] = i
+			}
+		}
+		for _, c := range node.Content {
+			visit(c)
+		}
+	}
+	visit(&root)
+
+	result := make([]commentLine, 0, len(found))
+	for line, column := range found {
+		result = append(result, commentLine{line: line, column: column})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].line < result[j].line })
+	return result
+}
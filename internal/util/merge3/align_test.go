@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_AlignComments(t *testing.T) {
+	var testCases = []struct {
+		description   string
+		origin        string
+		update        string
+		local         string
+		expected      string
+		alignComments bool
+	}{
+		{
+			description: `aligned comments survive a no-op merge`,
+			origin: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+  image: nginx # the image
+`,
+			update: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+  image: nginx # the image
+`,
+			local: `
+kind: Foo # the kind
+spec:
+  replicas: 3        # the replicas
+  image: nginx       # the image
+  command: "a # b"   # the command
+  args: ['#', b]     # the args
+`,
+			expected: `
+kind: Foo # the kind
+spec:
+  replicas: 3        # the replicas
+  image: nginx       # the image
+  command: "a # b"   # the command
+  args: ['#', b]     # the args
+`,
+			alignComments: true,
+		},
+		{
+			description: `scalar content is not aligned`,
+			origin: `
+kind: Foo
+spec:
+  script: |
+    echo a  # b
+  name: it's # the name
+`,
+			update: `
+kind: Foo
+spec:
+  script: |
+    echo a # b
+  name: it's # the name
+`,
+			local: `
+kind: Foo
+spec:
+  script: |
+    echo a    # b
+  name: it's     # the name
+  title: don't # the title
+`,
+			expected: `
+kind: Foo
+spec:
+  script: |
+    echo a # b
+  name: it's     # the name
+  title: don't # the title
+`,
+			alignComments: true,
+		},
+		{
+			description: `changed lines are not aligned`,
+			origin: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+  image: nginx # the image
+`,
+			update: `
+kind: Foo
+spec:
+  replicas: 5 # the replicas
+  image: nginx # the image
+`,
+			local: `
+kind: Foo
+spec:
+  replicas: 3    # the replicas
+  image: nginx   # the image
+`,
+			expected: `
+kind: Foo
+spec:
+  replicas: 5 # the replicas
+  image: nginx   # the image
+`,
+			alignComments: true,
+		},
+		{
+			description: `comments are not aligned without the option`,
+			origin: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+`,
+			update: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+`,
+			local: `
+kind: Foo
+spec:
+  replicas: 3    # the replicas
+`,
+			expected: `
+kind: Foo
+spec:
+  replicas: 3 # the replicas
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{AlignComments: tc.alignComments}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// expandAliases replaces each alias of node in place with a copy of the
+// value of its anchor, so that aliases are merged like their content.  The
+// alias each expanded node replaced is recorded in aliases, if it isn't nil.
+func expandAliases(node *yaml.Node, aliases map[*yaml.Node]yaml.Node) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		alias := *node
+		*node = *yaml.CopyYNode(node.Alias)
+		node.Anchor = ""
+		if aliases != nil {
+			aliases[node] = alias
+		}
+	}
+	for _, n := range node.Content {
+		expandAliases(n, aliases)
+	}
+}
+
+// restoreAliases replaces each node of the merged result which was expanded
+// from a dest alias with the alias again, if the anchor is still in the
+// result and the node still has the anchor's content.
+func restoreAliases(result *yaml.RNode, aliases map[*yaml.Node]yaml.Node) error {
+	if len(aliases) == 0 || yaml.IsMissingOrNull(result) {
+		return nil
+	}
+	nodes := nodeSet(result.YNode(), map[*yaml.Node]bool{})
+	for node, alias := range aliases {
+		if !nodes[node] || !nodes[alias.Alias] || alias.Alias.Anchor != alias.Value {
+			continue
+		}
+		expanded, err := Hash(yaml.NewRNode(node))
+		if err != nil {
+			return err
+		}
+		anchor, err := Hash(yaml.NewRNode(alias.Alias))
+		if err != nil {
+			return err
+		}
+		if expanded == anchor {
+			*node = alias
+		}
+	}
+	return nil
+}
+
+// clearAnchor clears the anchor of node, so it is encoded like its content,
+// and returns a function restoring it.
+func clearAnchor(node *yaml.Node) func() {
+	anchor := node.Anchor
+	node.Anchor = ""
+	return func() {
+		node.Anchor = anchor
+	}
+}
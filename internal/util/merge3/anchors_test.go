@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_CompareResolvedAnchors(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+	}{
+		{
+			description: `renamed anchors aren't changes`,
+			origin: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+replicas: &replicas 1
+minReplicas: *replicas
+`,
+			update: `
+kind: Foo
+labels: &appLabels
+  app: web
+selector: *appLabels
+replicas: &count 1
+minReplicas: *count
+`,
+			local: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+replicas: &replicas 1
+minReplicas: *replicas
+`,
+			expected: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+replicas: &replicas 1
+minReplicas: *replicas
+`,
+		},
+		{
+			description: `an alias replaced by equal content isn't a change`,
+			origin: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+`,
+			update: `
+kind: Foo
+labels:
+  app: web
+selector:
+  app: web
+`,
+			local: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+`,
+			expected: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+`,
+		},
+		{
+			description: `changes to the content of an alias are merged`,
+			origin: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+`,
+			update: `
+kind: Foo
+labels: &labels
+  app: web
+selector:
+  app: web
+  tier: frontend
+`,
+			local: `
+kind: Foo
+labels: &labels
+  app: web
+selector: *labels
+`,
+			expected: `
+kind: Foo
+labels: &labels
+  app: web
+selector:
+  app: web
+  tier: frontend
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{CompareResolvedAnchors: true, ClassifyChanges: true}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			if tc.expected == tc.local {
+				for path, change := range report.Changes {
+					assert.Equal(t, ChangeNoop, change, path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// MergeSourceAnnotation records the upstream source of a resource.  It is
+// managed by the upstream package rather than merged with local changes.
+const MergeSourceAnnotation = "kpt.dev/merge-source"
+
+// MergeSourcePolicy controls how the MergeSourceAnnotation is merged.
+type MergeSourcePolicy int
+
+const (
+	// MergeSourceFromUpdate always takes the annotation from update,
+	// removing it from dest if update doesn't have it.
+	MergeSourceFromUpdate MergeSourcePolicy = iota
+
+	// MergeSourceKeepDest always keeps the annotation from dest.
+	MergeSourceKeepDest
+
+	// MergeSourceMerge merges the annotation like any other field.
+	MergeSourceMerge
+)
+
+// mergeSource returns the merged value of the MergeSourceAnnotation, and
+// true if path is the annotation and it is not merged like other fields.
+func (m Visitor) mergeSource(nodes walk.Sources, path []string) (*yaml.RNode, bool) {
+	if !isAnnotation(path, MergeSourceAnnotation) {
+		return nil, false
+	}
+	switch m.MergeSource {
+	case MergeSourceFromUpdate:
+		return nodes.Updated(), true
+	case MergeSourceKeepDest:
+		return nodes.Dest(), true
+	default:
+		return nil, false
+	}
+}
+
+// isAnnotation returns true if path is the resource annotation with name.
+func isAnnotation(path []string, name string) bool {
+	return len(path) == 3 && path[0] == "metadata" && path[1] == "annotations" && path[2] == name
+}
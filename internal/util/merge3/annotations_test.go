@@ -0,0 +1,149 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_MergeSource(t *testing.T) {
+	var testCases = []struct {
+		description string
+		policy      MergeSourcePolicy
+		origin      string
+		update      string
+		local       string
+		expected    string
+	}{
+		{
+			description: `merge-source is synced from update when dest changed it`,
+			origin: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1
+    team: a`,
+			update: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1
+    team: b`,
+			local: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local
+    team: c`,
+			expected: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1
+    team: b`,
+		},
+		{
+			description: `merge-source is removed when update removed it`,
+			origin: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1
+    team: a`,
+			update: `
+metadata:
+  annotations:
+    team: a`,
+			local: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local
+    team: c`,
+			expected: `
+metadata:
+  annotations:
+    team: c`,
+		},
+		{
+			description: `merge-source is added from update`,
+			origin: `
+metadata:
+  name: foo`,
+			update: `
+metadata:
+  name: foo
+  annotations:
+    kpt.dev/merge-source: repo@v2`,
+			local: `
+metadata:
+  name: foo`,
+			expected: `
+metadata:
+  name: foo
+  annotations:
+    kpt.dev/merge-source: repo@v2`,
+		},
+		{
+			description: `merge-source is kept from dest`,
+			policy:      MergeSourceKeepDest,
+			origin: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1`,
+			update: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v2`,
+			local: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local`,
+			expected: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local`,
+		},
+		{
+			description: `merge-source is merged like other fields`,
+			policy:      MergeSourceMerge,
+			origin: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1`,
+			update: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: repo@v1`,
+			local: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local`,
+			expected: `
+metadata:
+  annotations:
+    kpt.dev/merge-source: local`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{MergeSource: tc.policy}.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// appendText returns dest with the text update appended to origin, if the
+// field at path is configured in AppendOnlyText.  It returns false if update
+// doesn't extend origin, e.g. because update rewrote the text, so that the
+// field is merged as usual.
+func (m Visitor) appendText(nodes walk.Sources, path []string) (*yaml.RNode, string, bool) {
+	if !containsPath(m.AppendOnlyText, path) {
+		return nil, "", false
+	}
+	if !isScalar(nodes.Dest()) || !isScalar(nodes.Updated()) {
+		return nil, "", false
+	}
+	var origin string
+	if !yaml.IsMissingOrNull(nodes.Origin()) {
+		if !isScalar(nodes.Origin()) {
+			return nil, "", false
+		}
+		origin = nodes.Origin().YNode().Value
+	}
+	update := nodes.Updated().YNode().Value
+	if !strings.HasPrefix(update, origin) {
+		return nil, "", false
+	}
+	m.matchRule(pathRule("AppendOnlyText", path), path)
+
+	added := update[len(origin):]
+	if added == "" {
+		return nodes.Dest(), "kept dest because update appended no text", true
+	}
+	dest := *nodes.Dest().YNode()
+	if dest.Value != "" && strings.HasSuffix(origin, "\n") && !strings.HasSuffix(dest.Value, "\n") {
+		// the text update appended starts on a new line
+		dest.Value += "\n"
+	}
+	dest.Value += added
+	if strings.Contains(dest.Value, "\n") && dest.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.DoubleQuotedStyle) == 0 {
+		dest.Style = yaml.LiteralStyle
+	}
+	return yaml.NewRNode(&dest), "appended the text update appended to origin to dest", true
+}
+
+// isScalar returns true if node is a non-null scalar.
+func isScalar(node *yaml.RNode) bool {
+	return !yaml.IsMissingOrNull(node) && node.YNode().Kind == yaml.ScalarNode
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_AppendOnlyText(t *testing.T) {
+	var testCases = []struct {
+		description    string
+		origin         string
+		update         string
+		local          string
+		expected       string
+		appendOnlyText []string
+	}{
+		{
+			description: `lines update appended are appended to dest`,
+			origin: `
+kind: Foo
+notes: |
+  v1: initial release
+`,
+			update: `
+kind: Foo
+notes: |
+  v1: initial release
+  v2: upstream fix
+`,
+			local: `
+kind: Foo
+notes: |
+  v1: initial release
+  local: patched the image
+`,
+			expected: `
+kind: Foo
+notes: |
+  v1: initial release
+  local: patched the image
+  v2: upstream fix
+`,
+			appendOnlyText: []string{"notes"},
+		},
+		{
+			description: `appended lines start on a new line`,
+			origin: `
+kind: Foo
+notes: |
+  v1
+`,
+			update: `
+kind: Foo
+notes: |
+  v1
+  v2
+`,
+			local: `
+kind: Foo
+notes: |-
+  v1
+  local`,
+			expected: `
+kind: Foo
+notes: |
+  v1
+  local
+  v2
+`,
+			appendOnlyText: []string{"notes"},
+		},
+		{
+			description: `text update added is appended to dest`,
+			origin: `
+kind: Foo
+`,
+			update: `
+kind: Foo
+notes: |
+  v1
+`,
+			local: `
+kind: Foo
+notes: |
+  local
+`,
+			expected: `
+kind: Foo
+notes: |
+  local
+  v1
+`,
+			appendOnlyText: []string{"notes"},
+		},
+		{
+			description: `unchanged update keeps dest`,
+			origin: `
+kind: Foo
+notes: v1
+`,
+			update: `
+kind: Foo
+notes: v1
+`,
+			local: `
+kind: Foo
+notes: v1 and local
+`,
+			expected: `
+kind: Foo
+notes: v1 and local
+`,
+			appendOnlyText: []string{"notes"},
+		},
+		{
+			description: `rewritten text is merged as usual`,
+			origin: `
+kind: Foo
+notes: |
+  v1
+`,
+			update: `
+kind: Foo
+notes: |
+  v2
+`,
+			local: `
+kind: Foo
+notes: |
+  v1
+`,
+			expected: `
+kind: Foo
+notes: |
+  v2
+`,
+			appendOnlyText: []string{"notes"},
+		},
+		{
+			description: `update replaces the text without the option`,
+			origin: `
+kind: Foo
+notes: |
+  v1
+`,
+			update: `
+kind: Foo
+notes: |
+  v1
+  v2
+`,
+			local: `
+kind: Foo
+notes: |
+  v1
+  local
+`,
+			expected: `
+kind: Foo
+notes: |
+  v1
+  v2
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{AppendOnlyText: tc.appendOnlyText}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
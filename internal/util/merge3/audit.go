@@ -0,0 +1,117 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// Audit configures the per-field audit log recorded in Report.Audit.
+type Audit struct {
+	// Now returns the time of the merge.  Defaults to time.Now.
+	Now func() time.Time
+}
+
+// AuditEntry records how the value of a field which isn't merged field by
+// field was chosen, so that merges can be archived for compliance.
+type AuditEntry struct {
+	// Path is the path to the field.
+	Path string `json:"path"`
+
+	// Origin, Dest and Update are the values of the field in each source,
+	// and Result is the merged value.
+	Origin string `json:"origin"`
+	Dest   string `json:"dest"`
+	Update string `json:"update"`
+	Result string `json:"result"`
+
+	// Decision is the source the merged value was taken from.
+	Decision DecisionSource `json:"decision"`
+
+	// Reason explains the decision, e.g. "kept dest because update==origin".
+	Reason string `json:"reason,omitempty"`
+
+	// Strategy is the ConflictStrategy which resolved the field, if it was
+	// a conflict, e.g. "take-update".
+	Strategy string `json:"strategy,omitempty"`
+
+	// Time is the time of the merge in RFC 3339 format.
+	Time string `json:"time"`
+}
+
+// auditLog contains the state used to record AuditEntries during a merge.
+type auditLog struct {
+	// time is the time of the merge.
+	time string
+
+	// strategies contains the ConflictStrategy used for each conflict.
+	strategies map[string]ConflictStrategy
+}
+
+// start returns the auditLog for a merge started now.
+func (a Audit) start() *auditLog {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	return &auditLog{
+		time:       now().UTC().Format(time.RFC3339),
+		strategies: map[string]ConflictStrategy{},
+	}
+}
+
+// recordAudit records an AuditEntry for node, the merged value of nodes at
+// path.
+func (m Visitor) recordAudit(path []string, nodes walk.Sources, node *yaml.RNode) {
+	if m.report.audit == nil {
+		return
+	}
+	p := pathString(path)
+	e := AuditEntry{
+		Path:     p,
+		Origin:   displayValue(nodes.Origin()),
+		Dest:     displayValue(nodes.Dest()),
+		Update:   displayValue(nodes.Updated()),
+		Result:   displayValue(node),
+		Decision: decisionSource(nodes, node),
+		Reason:   m.report.explanations[p],
+		Time:     m.report.audit.time,
+	}
+	if strategy, found := m.report.audit.strategies[p]; found {
+		e.Strategy = strategy.String()
+	}
+	m.report.Audit = append(m.report.Audit, e)
+}
+
+// recordStrategy records the ConflictStrategy used to resolve the conflict
+// at path.
+func (m Visitor) recordStrategy(path string, strategy ConflictStrategy) {
+	if m.report.audit != nil {
+		m.report.audit.strategies[path] = strategy
+	}
+}
+
+// String returns the name of the ConflictStrategy, e.g. "take-update".
+func (s ConflictStrategy) String() string {
+	switch s {
+	case TakeDest:
+		return "take-dest"
+	default:
+		return "take-update"
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_Audit(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  replicas: 1
+  paused: false
+  image: nginx:1.7
+  args: [a]
+`
+	update := `
+kind: Deployment
+spec:
+  replicas: 2
+  paused: false
+  image: nginx:1.8
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 3
+  paused: true
+  image: nginx:1.7
+  args: [a]
+`
+
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("PST", -8*60*60))
+	_, report, err := Visitor{
+		ConflictStrategy: TakeDest,
+		Audit:            &Audit{Now: func() time.Time { return now }},
+	}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []AuditEntry{
+		{
+			Path: "kind", Origin: "Deployment", Dest: "Deployment", Update: "Deployment",
+			Result: "Deployment", Decision: SourceDest,
+			Reason: "kept dest because update==origin", Time: "2021-03-04T13:06:07Z",
+		},
+		{
+			Path: "spec.args", Origin: "[a]", Dest: "[a]", Update: "",
+			Result: "", Decision: SourceDeleted,
+			Reason: "deleted because update removed it", Time: "2021-03-04T13:06:07Z",
+		},
+		{
+			Path: "spec.image", Origin: "nginx:1.7", Dest: "nginx:1.7", Update: "nginx:1.8",
+			Result: "nginx:1.8", Decision: SourceUpdate,
+			Reason: "took update because dest==origin and update!=origin", Time: "2021-03-04T13:06:07Z",
+		},
+		{
+			Path: "spec.paused", Origin: "false", Dest: "true", Update: "false",
+			Result: "true", Decision: SourceDest,
+			Reason: "kept dest because update==origin", Time: "2021-03-04T13:06:07Z",
+		},
+		{
+			Path: "spec.replicas", Origin: "1", Dest: "3", Update: "2",
+			Result: "3", Decision: SourceDest,
+			Reason: "kept dest because update!=origin and dest!=origin", Strategy: "take-dest",
+			Time: "2021-03-04T13:06:07Z",
+		},
+	}, report.Audit)
+
+	// the entries are serialized for archival
+	b, err := json.Marshal(report.Audit[4])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{
+  "path": "spec.replicas",
+  "origin": "1",
+  "dest": "3",
+  "update": "2",
+  "result": "3",
+  "decision": "dest",
+  "reason": "kept dest because update!=origin and dest!=origin",
+  "strategy": "take-dest",
+  "time": "2021-03-04T13:06:07Z"
+}`, string(b))
+
+	// the audit log is only recorded when requested
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.Audit)
+}
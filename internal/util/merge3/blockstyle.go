@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// adoptsBlockStyle returns true if update only changed the scalar in origin
+// to or from a block scalar (e.g. `"a\nb\n"` to `|`) without changing its
+// value, dest is unchanged from origin, and AdoptBlockStyle is set.
+func (m Visitor) adoptsBlockStyle(nodes walk.Sources) bool {
+	if !m.AdoptBlockStyle {
+		return false
+	}
+	for _, n := range nodes {
+		if yaml.IsMissingOrNull(n) || n.YNode().Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	origin, update, dest := nodes.Origin().YNode(), nodes.Updated().YNode(), nodes.Dest().YNode()
+	return origin.Value == update.Value && isBlock(origin) != isBlock(update) &&
+		dest.Value == origin.Value && isBlock(dest) == isBlock(origin)
+}
+
+// keepsStyle returns true if the merged value val of the field with the
+// values nodes must keep its own style, rather than taking the style of the
+// dest field when it is set.
+func (m Visitor) keepsStyle(nodes walk.Sources, val *yaml.RNode) bool {
+	return !yaml.IsMissingOrNull(val) && len(nodes) > walk.UpdatedIndex &&
+		!yaml.IsMissingOrNull(nodes.Updated()) && val.YNode() == nodes.Updated().YNode() &&
+		m.adoptsBlockStyle(nodes)
+}
+
+// isBlock returns true if node is a literal or folded block scalar.
+func isBlock(node *yaml.Node) bool {
+	return node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+}
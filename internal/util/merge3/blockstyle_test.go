@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_AdoptBlockStyle(t *testing.T) {
+	var testCases = []struct {
+		description     string
+		origin          string
+		update          string
+		local           string
+		expected        string
+		adoptBlockStyle bool
+	}{
+		{
+			description: `scalar changed to a block is adopted`,
+			origin: `
+script: "echo a\necho b"`,
+			update: `
+script: |-
+  echo a
+  echo b`,
+			local: `
+script: "echo a\necho b"`,
+			expected: `
+script: |-
+  echo a
+  echo b`,
+			adoptBlockStyle: true,
+		},
+		{
+			description: `block changed to a scalar is adopted`,
+			origin: `
+script: |-
+  echo a
+  echo b`,
+			update: `
+script: "echo a\necho b"`,
+			local: `
+script: |-
+  echo a
+  echo b`,
+			expected: `
+script: "echo a\necho b"`,
+			adoptBlockStyle: true,
+		},
+		{
+			description: `scalar changed to a block keeps dest without the option`,
+			origin: `
+script: "echo a\necho b"`,
+			update: `
+script: |-
+  echo a
+  echo b`,
+			local: `
+script: "echo a\necho b"`,
+			expected: `
+script: "echo a\necho b"`,
+		},
+		{
+			description: `block style changed in dest is kept`,
+			origin: `
+script: "echo a\necho b"`,
+			update: `
+script: |-
+  echo a
+  echo b`,
+			local: `
+script: >-
+  echo a
+
+  echo b`,
+			expected: `
+script: >-
+  echo a
+
+  echo b`,
+			adoptBlockStyle: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{AdoptBlockStyle: tc.adoptBlockStyle}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// canonicalNumber returns the canonical rendering of the value of node and
+// true if node is an int or float.  Ints are written in decimal, and floats
+// as by canonicalFloat, so that e.g. `1000000`, `1e6` and `1.0e+06` are all
+// written `1000000`.
+func canonicalNumber(node *yaml.RNode) (string, bool) {
+	if yaml.IsMissingOrNull(node) || node.YNode().Kind != yaml.ScalarNode {
+		return "", false
+	}
+	value := node.YNode().Value
+	switch node.YNode().ShortTag() {
+	case yaml.NodeTagInt:
+		if i, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return strconv.FormatInt(i, 10), true
+		}
+	case yaml.NodeTagFloat:
+	default:
+		return "", false
+	}
+	f, ok := parseFloat(value)
+	if !ok {
+		return "", false
+	}
+	return canonicalFloat(f), true
+}
+
+// parseFloat parses a YAML float, including `.inf`, `-.inf` and `.nan`.
+func parseFloat(value string) (float64, bool) {
+	switch strings.ToLower(strings.TrimPrefix(value, "+")) {
+	case ".inf":
+		return math.Inf(1), true
+	case "-.inf":
+		return math.Inf(-1), true
+	case ".nan":
+		return math.NaN(), true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+// canonicalFloat returns the canonical rendering of f.  Infinities and NaN
+// are written `.inf`, `-.inf` and `.nan`, negative zero as `0`, and other
+// values with the fewest digits which represent them exactly, in decimal
+// notation if 1e-7 <= |f| < 1e21 and in exponent notation otherwise.
+func canonicalFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	case f == 0:
+		return "0"
+	}
+	if a := math.Abs(f); a >= 1e-7 && a < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'e', -1, 64)
+}
+
+// writeCanonicalFloat returns a copy of node with its value replaced by its
+// canonical rendering if node is a float and WriteCanonicalFloats is set,
+// and node otherwise.  Integral values are written with a `.0` suffix so
+// that they are still floats.  node is copied since it is usually one of the
+// sources.
+func (m Visitor) writeCanonicalFloat(node *yaml.RNode) *yaml.RNode {
+	if !m.WriteCanonicalFloats || yaml.IsMissingOrNull(node) ||
+		node.YNode().Kind != yaml.ScalarNode || node.YNode().ShortTag() != yaml.NodeTagFloat {
+		return node
+	}
+	f, ok := parseFloat(node.YNode().Value)
+	if !ok {
+		return node
+	}
+	s := canonicalFloat(f)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	if s == node.YNode().Value {
+		return node
+	}
+	n := yaml.CopyYNode(node.YNode())
+	n.Value = s
+	return yaml.NewRNode(n)
+}
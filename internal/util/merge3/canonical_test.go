@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_WriteCanonicalFloats(t *testing.T) {
+	var testCases = []struct {
+		value    string
+		expected string
+	}{
+		{value: `1.0`, expected: `1.0`},
+		{value: `1.50`, expected: `1.5`},
+		{value: `1e6`, expected: `1000000.0`},
+		{value: `1.0E+06`, expected: `1000000.0`},
+		{value: `-0.0`, expected: `0.0`},
+		{value: `0.1e-6`, expected: `0.0000001`},
+		{value: `1e-8`, expected: `1e-08`},
+		{value: `1e21`, expected: `1e+21`},
+		{value: `123456789.125`, expected: `123456789.125`},
+		{value: `.Inf`, expected: `.inf`},
+		{value: `+.INF`, expected: `.inf`},
+		{value: `-.inf`, expected: `-.inf`},
+		{value: `.NaN`, expected: `.nan`},
+		// ints and strings aren't rewritten
+		{value: `0x1F`, expected: `0x1F`},
+		{value: `"1.0"`, expected: `"1.0"`},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.value, func(t *testing.T) {
+			input := "value: " + tc.value
+			actual, _, err := Visitor{WriteCanonicalFloats: true}.MergeStrings(input, input, input)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, "value: "+tc.expected, strings.TrimSpace(actual))
+		})
+	}
+
+	// the sources are not modified
+	dest, origin, update := yaml.MustParse("f: 1.5\n"), yaml.MustParse("f: 1.5\n"), yaml.MustParse("f: 1.50\n")
+	actual, _, err := Visitor{WriteCanonicalFloats: true}.Merge(dest, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "f: 1.5\n", actual.MustString())
+	assert.Equal(t, "f: 1.50\n", update.MustString())
+	assert.Equal(t, "f: 1.5\n", origin.MustString())
+}
+
+func TestVisitor_CanonicalFloats(t *testing.T) {
+	var testCases = []struct {
+		description   string
+		origin        string
+		update        string
+		local         string
+		expected      string
+		canonical     bool
+		ignoreQuoting bool
+	}{
+		{
+			description: `infinity written differently is unchanged`,
+			origin:      `limit: .inf`,
+			update:      `limit: .Inf`,
+			local:       `limit: 5`,
+			expected:    `limit: 5`,
+			canonical:   true,
+		},
+		{
+			description: `infinity written differently is a change without the option`,
+			origin:      `limit: .inf`,
+			update:      `limit: .Inf`,
+			local:       `limit: 5`,
+			expected:    `limit: .Inf`,
+		},
+		{
+			description: `nan written differently is unchanged`,
+			origin:      `limit: .nan`,
+			update:      `limit: .NaN`,
+			local:       `limit: 5`,
+			expected:    `limit: 5`,
+			canonical:   true,
+		},
+		{
+			description: `int and exponent float are equal`,
+			origin:      `limit: 1000000`,
+			update:      `limit: 2e6`,
+			local:       `limit: 1e6`,
+			expected:    `limit: 2e6`,
+			canonical:   true,
+		},
+		{
+			description: `negative zero equals zero`,
+			origin:      `limit: 1`,
+			update:      `limit: 2`,
+			local:       `limit: -0.0`,
+			expected:    `limit: 2`,
+			canonical:   true,
+		},
+		{
+			description: `canonical nested values`,
+			origin:      `limits: [.inf, 1]`,
+			update:      `limits: [.Inf, 1.0]`,
+			local:       `limits: [.inf, 1, 2]`,
+			expected:    `limits: [.inf, 1, 2]`,
+			canonical:   true,
+			// lists are only normalized when ignoring quoting
+			ignoreQuoting: true,
+		},
+		{
+			description:   `quoted values are not normalized when ignoring quoting`,
+			origin:        `version: "1.10"`,
+			update:        `version: "1.1"`,
+			local:         `version: "1.10"`,
+			expected:      `version: "1.1"`,
+			ignoreQuoting: true,
+		},
+		{
+			description:   `quoted values are not canonicalized when ignoring quoting`,
+			origin:        `version: "1.10"`,
+			update:        `version: "1.1"`,
+			local:         `version: "1.10"`,
+			expected:      `version: "1.1"`,
+			canonical:     true,
+			ignoreQuoting: true,
+		},
+		{
+			description:   `numbers are normalized when ignoring quoting`,
+			origin:        `ratio: 1.10`,
+			update:        `ratio: '1.1'`,
+			local:         `ratio: 1.10`,
+			expected:      `ratio: 1.10`,
+			ignoreQuoting: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{
+				NormalizeNumbers: true,
+				CanonicalFloats:  tc.canonical,
+				IgnoreQuoting:    tc.ignoreQuoting,
+			}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// keepChomping returns the merged value node with the trailing newlines of
+// the dest block scalar, if it was taken from update, so that it keeps the
+// dest chomping indicator (`|-`, `|` or `|+`).  Only block scalars which have
+// the same style in dest and update, and whose trailing newlines update
+// didn't change from origin, are changed.  The update node isn't modified.
+func (m Visitor) keepChomping(nodes walk.Sources, node *yaml.RNode) *yaml.RNode {
+	if !m.PreserveChomping || yaml.IsMissingOrNull(node) || yaml.IsMissingOrNull(nodes.Origin()) ||
+		yaml.IsMissingOrNull(nodes.Dest()) || yaml.IsMissingOrNull(nodes.Updated()) {
+		return node
+	}
+	origin, dest, update := nodes.Origin().YNode(), nodes.Dest().YNode(), nodes.Updated().YNode()
+	if node.YNode() != update || !isBlock(dest) || dest.Style != update.Style {
+		return node
+	}
+	if !isBlock(origin) || trailingNewlines(origin.Value) != trailingNewlines(update.Value) {
+		// update changed the chomping of origin
+		return node
+	}
+	n := yaml.CopyYNode(update)
+	n.Value = strings.TrimRight(update.Value, "\n") + strings.Repeat("\n", trailingNewlines(dest.Value))
+	return yaml.NewRNode(n)
+}
+
+// trailingNewlines returns the number of newlines value ends with.
+func trailingNewlines(value string) int {
+	return len(value) - len(strings.TrimRight(value, "\n"))
+}
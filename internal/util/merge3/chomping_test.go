@@ -0,0 +1,182 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_PreserveChomping(t *testing.T) {
+	var testCases = []struct {
+		description      string
+		origin           string
+		update           string
+		local            string
+		expected         string
+		preserveChomping bool
+	}{
+		{
+			description: `strip indicator survives a value update`,
+			origin: `
+script: |
+  echo a
+`,
+			update: `
+script: |
+  echo b
+`,
+			local: `
+script: |-
+  echo a
+`,
+			expected: `
+script: |-
+  echo b
+`,
+			preserveChomping: true,
+		},
+		{
+			description: `clip indicator survives a value update`,
+			origin: `
+script: |-
+  echo a
+`,
+			update: `
+script: |-
+  echo b
+`,
+			local: `
+script: |
+  echo a
+`,
+			expected: `
+script: |
+  echo b
+`,
+			preserveChomping: true,
+		},
+		{
+			description: `keep indicator survives a value update`,
+			origin: `
+script: |
+  echo a
+kind: Foo
+`,
+			update: `
+script: |
+  echo b
+kind: Foo
+`,
+			local: `
+script: |+
+  echo a
+
+kind: Foo
+`,
+			expected: `
+script: |+
+  echo b
+
+kind: Foo
+`,
+			preserveChomping: true,
+		},
+		{
+			description: `indicator changed by update is taken`,
+			origin: `
+script: |-
+  echo a
+`,
+			update: `
+script: |
+  echo b
+`,
+			local: `
+script: |-
+  echo a
+`,
+			expected: `
+script: |
+  echo b
+`,
+			preserveChomping: true,
+		},
+		{
+			description: `kept dest value keeps its indicator`,
+			origin: `
+script: |
+  echo a
+`,
+			update: `
+script: |
+  echo a
+`,
+			local: `
+script: |-
+  echo local
+`,
+			expected: `
+script: |-
+  echo local
+`,
+			preserveChomping: true,
+		},
+		{
+			description: `update indicator is taken without the option`,
+			origin: `
+script: |-
+  echo a
+`,
+			update: `
+script: |
+  echo b
+`,
+			local: `
+script: |-
+  echo a
+`,
+			expected: `
+script: |
+  echo b
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{PreserveChomping: tc.preserveChomping}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+
+	// the update is not modified
+	update := yaml.MustParse("script: |\n  echo b\n")
+	_, _, err := Visitor{PreserveChomping: true}.Merge(
+		yaml.MustParse("script: |-\n  echo a\n"), yaml.MustParse("script: |\n  echo a\n"), update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "script: |\n  echo b\n", update.MustString())
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// ChangeKind classifies the effect of a merge on a field of dest.
+type ChangeKind string
+
+const (
+	// ChangeNoop is a field whose merged value is the dest value.
+	ChangeNoop ChangeKind = "noop"
+
+	// ChangeChanged is a field whose merged value differs from dest.
+	ChangeChanged ChangeKind = "changed"
+
+	// ChangeAdded is a field which dest didn't have.
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved is a field which the merge removed from dest.
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeConflict is a field which was changed in both dest and update.
+	ChangeConflict ChangeKind = "conflict"
+)
+
+// classifyChange records the ChangeKind of the field at path, whose merged
+// value is node.
+func (m Visitor) classifyChange(path []string, nodes walk.Sources, node *yaml.RNode) {
+	if !m.ClassifyChanges {
+		return
+	}
+	if m.report.Changes == nil {
+		m.report.Changes = map[string]ChangeKind{}
+	}
+	p := pathString(path)
+	dest, result := yaml.IsMissingOrNull(nodes.Dest()), yaml.IsMissingOrNull(node)
+	var kind ChangeKind
+	switch {
+	case m.report.conflictPaths[p]:
+		kind = ChangeConflict
+	case dest && result:
+		// missing from both dest and the result
+		return
+	case dest:
+		kind = ChangeAdded
+	case result:
+		kind = ChangeRemoved
+	case displayValue(nodes.Dest()) == displayValue(node):
+		kind = ChangeNoop
+	default:
+		kind = ChangeChanged
+	}
+	m.report.Changes[p] = kind
+}
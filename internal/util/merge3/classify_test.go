@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ClassifyChanges(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  replicas: 1
+  paused: false
+  image: nginx:1.7
+  args: [a]
+`
+	update := `
+kind: Deployment
+spec:
+  replicas: 2
+  paused: false
+  image: nginx:1.8
+  minReadySeconds: 5
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 3
+  paused: true
+  image: nginx:1.7
+  args: [a]
+`
+
+	_, report, err := Visitor{ClassifyChanges: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		"kind":                 ChangeNoop,
+		"spec.args":            ChangeRemoved,
+		"spec.image":           ChangeChanged,
+		"spec.minReadySeconds": ChangeAdded,
+		"spec.paused":          ChangeNoop,
+		"spec.replicas":        ChangeConflict,
+	}, report.Changes)
+
+	// the classification is serialized for dashboards
+	b, err := json.Marshal(report.Changes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{
+  "kind": "noop",
+  "spec.args": "removed",
+  "spec.image": "changed",
+  "spec.minReadySeconds": "added",
+  "spec.paused": "noop",
+  "spec.replicas": "conflict"
+}`, string(b))
+
+	// the classification is only recorded when requested
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.Changes)
+}
+
+func TestVisitor_ClassifyChanges_conflicts(t *testing.T) {
+	origin := `
+spec:
+  args: [a]
+  image: nginx:1.7
+  replicas: 1
+`
+	update := `
+spec:
+  args: [b]
+  image: nginx:1.8
+  replicas: 2
+`
+	local := `
+spec:
+  args: [c]
+  image: nginx:1.9
+  replicas: 1
+`
+
+	// every conflicting field is classified as a conflict, not only the
+	// most recently recorded one
+	_, report, err := Visitor{ClassifyChanges: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		"spec.args":     ChangeConflict,
+		"spec.image":    ChangeConflict,
+		"spec.replicas": ChangeChanged,
+	}, report.Changes)
+	assert.Len(t, report.Conflicts, 2)
+}
+
+func TestVisitor_ClassifyChanges_clearedCollections(t *testing.T) {
+	origin := `
+x: 1
+l:
+- name: a
+  v: 1
+m:
+  a: 1
+`
+	update := `
+x: 1
+m: null
+`
+
+	// lists and maps deleted as a whole are classified as removed
+	_, report, err := Visitor{ClassifyChanges: true, InferAssociativeLists: true}.
+		MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		"l": ChangeRemoved,
+		"m": ChangeRemoved,
+		"x": ChangeNoop,
+	}, report.Changes)
+}
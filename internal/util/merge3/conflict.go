@@ -0,0 +1,273 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"encoding/json"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// Conflict is a field which was changed to different values in dest and
+// update.  Conflicts are resolved using the Visitor ConflictStrategy, or the
+// strategy returned by OnConflict.
+type Conflict struct {
+	// Path is the path to the conflicting field.
+	Path string
+
+	// Origin, Dest and Update are the values of the field in each source.
+	Origin string
+	Dest   string
+	Update string
+
+	// Comments contains the comments on the field in each source, if the
+	// comments were also changed to different values in dest and update.
+	// Only populated if CommentConflicts is set.
+	Comments *ConflictComments
+
+	// Reason identifies how the Conflict was resolved.  Only populated if
+	// RecordConflictReasons is set.
+	Reason ConflictReason
+
+	// maxValueLength is the MaxConflictValueLength of the Visitor.
+	maxValueLength int
+}
+
+// ConflictReason is a machine-readable code identifying how a Conflict was
+// resolved.
+type ConflictReason string
+
+const (
+	// ReasonTakeUpdate is a Conflict resolved by the TakeUpdate
+	// ConflictStrategy of the Visitor.
+	ReasonTakeUpdate ConflictReason = "take-update"
+
+	// ReasonTakeDest is a Conflict resolved by the TakeDest ConflictStrategy
+	// of the Visitor.
+	ReasonTakeDest ConflictReason = "take-dest"
+
+	// ReasonResolver is a Conflict resolved by the strategy OnConflict
+	// returned.
+	ReasonResolver ConflictReason = "resolver"
+
+	// ReasonKindStrategy is a Conflict resolved by the ConflictStrategy a
+	// Registry configures for the kind of the resource.
+	ReasonKindStrategy ConflictReason = "kind-strategy"
+)
+
+// ConflictComments are the comments on a conflicting field in each source.
+type ConflictComments struct {
+	Origin string `json:"origin"`
+	Dest   string `json:"dest"`
+	Update string `json:"update"`
+}
+
+// MarshalJSON returns the Conflict as a JSON object with the path, kind and
+// values of the conflicting field, so it can be parsed by CI systems.  The
+// values are truncated to the Visitor MaxConflictValueLength.
+func (c Conflict) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Path     string            `json:"path"`
+		Kind     string            `json:"kind"`
+		Values   conflictValues    `json:"values"`
+		Comments *ConflictComments `json:"comments,omitempty"`
+		Reason   ConflictReason    `json:"reason,omitempty"`
+	}{
+		Path: c.Path,
+		Kind: "conflict",
+		Values: conflictValues{
+			Origin: truncate(c.Origin, c.maxValueLength),
+			Dest:   truncate(c.Dest, c.maxValueLength),
+			Update: truncate(c.Update, c.maxValueLength),
+		},
+		Comments: c.Comments,
+		Reason:   c.Reason,
+	})
+}
+
+type conflictValues struct {
+	Origin string `json:"origin"`
+	Dest   string `json:"dest"`
+	Update string `json:"update"`
+}
+
+// ConflictGroup is a set of Conflicts whose paths only differ by the
+// associative list elements they are in.
+type ConflictGroup struct {
+	// Pattern is the path of the Conflicts with the list elements replaced
+	// by `[*]`, e.g. `spec.containers[*].image`.
+	Pattern string `json:"pattern"`
+
+	// Count is the number of Conflicts in the group.
+	Count int `json:"count"`
+}
+
+// recordConflict records that the field at path was changed in both dest
+// and update, and returns the ConflictStrategy used to resolve it.
+func (m Visitor) recordConflict(path []string, nodes walk.Sources) (ConflictStrategy, error) {
+	if m.report == nil {
+		return m.ConflictStrategy, nil
+	}
+	c := Conflict{
+		Path:   pathString(path),
+		Origin: displayValue(nodes.Origin()),
+		Dest:   displayValue(nodes.Dest()),
+		Update: displayValue(nodes.Updated()),
+
+		maxValueLength: m.MaxConflictValueLength,
+	}
+	if m.CommentConflicts {
+		comments := ConflictComments{
+			Origin: comment(m.key(walk.OriginIndex), nodes.Origin()),
+			Dest:   comment(m.key(walk.DestIndex), nodes.Dest()),
+			Update: comment(m.key(walk.UpdatedIndex), nodes.Updated()),
+		}
+		if comments.Dest != comments.Origin && comments.Update != comments.Origin &&
+			comments.Dest != comments.Update {
+			c.Comments = &comments
+		}
+	}
+	m.report.Conflicts = append(m.report.Conflicts, c)
+	if m.report.conflictPaths == nil {
+		m.report.conflictPaths = map[string]bool{}
+	}
+	m.report.conflictPaths[c.Path] = true
+
+	strategy, reason := m.ConflictStrategy, m.strategyReason()
+	if m.OnConflict != nil {
+		s, err := m.OnConflict(c)
+		if err != nil {
+			return 0, errors.WrapPrefixf(err, "%s", c.Path)
+		}
+		if s != 0 {
+			strategy, reason = s, ReasonResolver
+		}
+	}
+	if m.RecordConflictReasons {
+		m.report.Conflicts[len(m.report.Conflicts)-1].Reason = reason
+	}
+	m.recordStrategy(c.Path, strategy)
+	return strategy, nil
+}
+
+// strategyReason returns the ConflictReason of Conflicts resolved by the
+// ConflictStrategy.
+func (m Visitor) strategyReason() ConflictReason {
+	switch {
+	case m.kindStrategy:
+		return ReasonKindStrategy
+	case m.ConflictStrategy == TakeDest:
+		return ReasonTakeDest
+	default:
+		return ReasonTakeUpdate
+	}
+}
+
+// key returns the key of the field being merged in the source at index, or
+// nil if the field isn't in a map.
+func (m Visitor) key(index int) *yaml.RNode {
+	if index >= len(m.keys) {
+		return nil
+	}
+	return m.keys[index]
+}
+
+// comment returns the comments on a field, which are on its key, e.g. head
+// comments, and on its value, e.g. line comments on scalars.
+func comment(key, value *yaml.RNode) string {
+	var comments []string
+	for _, node := range []*yaml.RNode{key, value} {
+		if yaml.IsMissingOrNull(node) {
+			continue
+		}
+		for _, c := range []string{node.YNode().HeadComment, node.YNode().LineComment, node.YNode().FootComment} {
+			if c != "" {
+				comments = append(comments, c)
+			}
+		}
+	}
+	return strings.Join(comments, "\n")
+}
+
+// displayValue returns the value of node formatted for reporting.
+func displayValue(node *yaml.RNode) string {
+	if yaml.IsMissingOrNull(node) {
+		return ""
+	}
+	if node.YNode().Kind == yaml.ScalarNode {
+		return node.YNode().Value
+	}
+	n := *node.YNode()
+	n.Style = yaml.FlowStyle
+	s, err := yaml.NewRNode(&n).String()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
+
+// truncate returns the first max characters of value followed by `...`, or
+// value if it isn't longer than max or max is zero.
+func truncate(value string, max int) string {
+	if max <= 0 {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max]) + "..."
+}
+
+// groupConflicts groups conflicts by their path pattern, in the order each
+// pattern is first seen.
+func groupConflicts(conflicts []Conflict) []ConflictGroup {
+	var groups []ConflictGroup
+	index := map[string]int{}
+	for _, c := range conflicts {
+		pattern := pathPattern(c.Path)
+		i, found := index[pattern]
+		if !found {
+			index[pattern] = len(groups)
+			groups = append(groups, ConflictGroup{Pattern: pattern, Count: 1})
+			continue
+		}
+		groups[i].Count++
+	}
+	return groups
+}
+
+// pathPattern replaces the associative list elements in path with `[*]`.
+func pathPattern(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "[")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start:], "]")
+		if end < 0 {
+			break
+		}
+		b.WriteString(path[:start])
+		b.WriteString("[*]")
+		path = path[start+end+1:]
+	}
+	b.WriteString(path)
+	return b.String()
+}
@@ -0,0 +1,469 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_Conflicts(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  args: [a]
+  same: a
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  args: [b]
+  same: b
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3
+  args: [c]
+  same: b
+`
+
+	_, report, err := Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Conflict{
+		{Path: "spec.args", Origin: "[a]", Dest: "[c]", Update: "[b]"},
+		{Path: "spec.replicas", Origin: "1", Dest: "3", Update: "2"},
+	}, report.Conflicts)
+	assert.Empty(t, report.ConflictGroups)
+}
+
+func TestVisitor_GroupConflicts(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  containers:
+  - name: a
+    imagePullPolicy: IfNotPresent
+  - name: b
+    imagePullPolicy: IfNotPresent
+  - name: c
+    imagePullPolicy: IfNotPresent
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  containers:
+  - name: a
+    imagePullPolicy: Always
+  - name: b
+    imagePullPolicy: Always
+  - name: c
+    imagePullPolicy: Always
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3
+  containers:
+  - name: a
+    imagePullPolicy: Never
+  - name: b
+    imagePullPolicy: Never
+  - name: c
+    imagePullPolicy: Never
+`
+
+	_, report, err := Visitor{InferAssociativeLists: true, GroupConflicts: true}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []ConflictGroup{
+		{Pattern: "spec.containers[*].imagePullPolicy", Count: 3},
+		{Pattern: "spec.replicas", Count: 1},
+	}, report.ConflictGroups)
+
+	// the detailed conflicts are still available
+	assert.Len(t, report.Conflicts, 4)
+	assert.Equal(t, Conflict{
+		Path:   "spec.containers[name=b].imagePullPolicy",
+		Origin: "IfNotPresent",
+		Dest:   "Never",
+		Update: "Always",
+	}, report.Conflicts[1])
+}
+
+func TestConflict_MarshalJSON(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.7
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.8
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3
+  image: nginx:1.9
+`
+
+	_, report, err := Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b, err := json.Marshal(report.Conflicts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `[
+  {
+    "path": "spec.image",
+    "kind": "conflict",
+    "values": {"origin": "nginx:1.7", "dest": "nginx:1.9", "update": "nginx:1.8"}
+  },
+  {
+    "path": "spec.replicas",
+    "kind": "conflict",
+    "values": {"origin": "1", "dest": "3", "update": "2"}
+  }
+]`, string(b))
+}
+
+func TestConflict_MarshalJSON_maxValueLength(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  command: [run, --port, "80"]
+  image: nginx
+`
+	update := `
+kind: Foo
+spec:
+  command: [run, --port, "8080"]
+  image: nginx:1.8
+`
+	local := `
+kind: Foo
+spec:
+  command: [run, --verbose]
+  image: nginx:1.9
+`
+
+	_, report, err := Visitor{MaxConflictValueLength: 8}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b, err := json.Marshal(report.Conflicts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `[
+  {
+    "path": "spec.command",
+    "kind": "conflict",
+    "values": {"origin": "[run, --...", "dest": "[run, --...", "update": "[run, --..."}
+  },
+  {
+    "path": "spec.image",
+    "kind": "conflict",
+    "values": {"origin": "nginx", "dest": "nginx:1....", "update": "nginx:1...."}
+  }
+]`, string(b))
+
+	// the Conflicts contain the full values
+	assert.Equal(t, "[run, --verbose]", report.Conflicts[0].Dest)
+	assert.Equal(t, "nginx:1.8", report.Conflicts[1].Update)
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	_, _, err := Visitor{InferAssociativeLists: true, MaxListGrowth: 1}.MergeStrings(
+		"items: [{name: a}]",
+		"items: [{name: a}]",
+		"items: [{name: a}, {name: b}, {name: c}]")
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	b, err := json.Marshal(err)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{
+  "path": "items",
+  "kind": "list-growth",
+  "message": "list items gained 2 elements, more than the maximum of 1"
+}`, string(b))
+}
+
+func TestVisitor_CommentConflicts(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1 # origin
+  image: nginx:1.7 # origin
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2 # update
+  image: nginx:1.8 # origin
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3 # local
+  image: nginx:1.9 # local
+`
+
+	_, report, err := Visitor{CommentConflicts: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Conflict{
+		{Path: "spec.image", Origin: "nginx:1.7", Dest: "nginx:1.9", Update: "nginx:1.8"},
+		{Path: "spec.replicas", Origin: "1", Dest: "3", Update: "2",
+			Comments: &ConflictComments{Origin: "# origin", Dest: "# local", Update: "# update"}},
+	}, report.Conflicts)
+
+	b, err := json.Marshal(report.Conflicts[1])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{
+  "path": "spec.replicas",
+  "kind": "conflict",
+  "values": {"origin": "1", "dest": "3", "update": "2"},
+  "comments": {"origin": "# origin", "dest": "# local", "update": "# update"}
+}`, string(b))
+
+	// comments are not reported without the option
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Nil(t, report.Conflicts[1].Comments)
+}
+
+func TestVisitor_OnConflict(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [a]
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.8
+  args: [b]
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3
+  image: nginx:1.9
+  args: [c]
+`
+	expected := `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.9
+  args: [c]
+`
+
+	// resolve each conflict as it is found, keeping the local image and args
+	var seen []string
+	resolve := func(c Conflict) (ConflictStrategy, error) {
+		seen = append(seen, c.Path)
+		switch c.Path {
+		case "spec.image":
+			assert.Equal(t, Conflict{Path: "spec.image", Origin: "nginx:1.7", Dest: "nginx:1.9", Update: "nginx:1.8"}, c)
+			return TakeDest, nil
+		case "spec.args":
+			return TakeDest, nil
+		default:
+			return 0, nil
+		}
+	}
+	actual, report, err := Visitor{OnConflict: resolve}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+	assert.Equal(t, []string{"spec.args", "spec.image", "spec.replicas"}, seen)
+	assert.Len(t, report.Conflicts, 3)
+
+	// the merge is aborted if the conflict can't be resolved
+	_, _, err = Visitor{OnConflict: func(Conflict) (ConflictStrategy, error) {
+		return 0, fmt.Errorf("unresolved")
+	}}.MergeStrings(local, origin, update)
+	assert.EqualError(t, err, "spec.args: unresolved")
+}
+
+func TestVisitor_RecordConflictReasons(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  a: origin
+  b: origin
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  a: update
+  b: update
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  a: local
+  b: local
+`
+
+	reasons := func(report *Report) map[string]ConflictReason {
+		r := map[string]ConflictReason{}
+		for _, c := range report.Conflicts {
+			r[c.Path] = c.Reason
+		}
+		return r
+	}
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		expected    map[string]ConflictReason
+	}{
+		{
+			description: `default strategy`,
+			visitor:     Visitor{RecordConflictReasons: true},
+			expected:    map[string]ConflictReason{"data.a": ReasonTakeUpdate, "data.b": ReasonTakeUpdate},
+		},
+		{
+			description: `take dest strategy`,
+			visitor:     Visitor{RecordConflictReasons: true, ConflictStrategy: TakeDest},
+			expected:    map[string]ConflictReason{"data.a": ReasonTakeDest, "data.b": ReasonTakeDest},
+		},
+		{
+			description: `resolver`,
+			visitor: Visitor{
+				RecordConflictReasons: true,
+				OnConflict: func(c Conflict) (ConflictStrategy, error) {
+					if c.Path == "data.a" {
+						return TakeDest, nil
+					}
+					// fall back to the ConflictStrategy
+					return 0, nil
+				},
+			},
+			expected: map[string]ConflictReason{"data.a": ReasonResolver, "data.b": ReasonTakeUpdate},
+		},
+		{
+			description: `without the option`,
+			visitor:     Visitor{},
+			expected:    map[string]ConflictReason{"data.a": "", "data.b": ""},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			_, report, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, reasons(report))
+		})
+	}
+
+	// the strategy configured for the kind by a Registry
+	_, reports, err := Registry{
+		Default:            Visitor{RecordConflictReasons: true},
+		ConflictStrategies: map[string]ConflictStrategy{"ConfigMap": TakeDest},
+	}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]ConflictReason{"data.a": ReasonKindStrategy, "data.b": ReasonKindStrategy},
+		reasons(reports["v1/ConfigMap//a"]))
+
+	// the reason is included in the JSON report
+	b, err := json.Marshal(reports["v1/ConfigMap//a"].Conflicts[0])
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{
+  "path": "data.a",
+  "kind": "conflict",
+  "values": {"origin": "origin", "dest": "local", "update": "update"},
+  "reason": "kind-strategy"
+}`, string(b))
+}
+
+func TestVisitor_CommentConflicts_headComments(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  # origin
+  replicas: 1
+`
+	update := `
+kind: Foo
+spec:
+  # update
+  replicas: 2
+`
+	local := `
+kind: Foo
+spec:
+  # local
+  replicas: 3
+`
+
+	_, report, err := Visitor{CommentConflicts: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []Conflict{
+		{Path: "spec.replicas", Origin: "1", Dest: "3", Update: "2",
+			Comments: &ConflictComments{Origin: "# origin", Dest: "# local", Update: "# update"}},
+	}, report.Conflicts)
+}
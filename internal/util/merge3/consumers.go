@@ -0,0 +1,148 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ConsumersReport contains information collected while merging a base
+// package into several consumers of it.
+type ConsumersReport struct {
+	// Reports contains the Report of the merge into each consumer, keyed by
+	// the consumer name.
+	Reports map[string]*Report
+
+	// Conflicts contains the fields which different consumers changed from
+	// the base to different values.
+	Conflicts []ConsumerConflict
+}
+
+// ConsumerConflict is a field of the base which several consumers changed to
+// different values.
+type ConsumerConflict struct {
+	// Path is the path to the field.
+	Path string `json:"path"`
+
+	// Origin is the value of the field in the base.
+	Origin string `json:"origin"`
+
+	// Values maps the name of each consumer which changed the field to its
+	// value in the consumer.  Fields a consumer removed have empty values.
+	Values map[string]string `json:"values"`
+}
+
+// MergeConsumers merges the changes between original and update, the old and
+// new versions of a base package, into each of the consumers which customize
+// the base.  The consumers are keyed by name.  It also reports the fields
+// which different consumers customized inconsistently.
+func (m Visitor) MergeConsumers(original, update *yaml.RNode, consumers map[string]*yaml.RNode) (map[string]*yaml.RNode, *ConsumersReport, error) {
+	var names []string
+	for name := range consumers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &ConsumersReport{Reports: map[string]*Report{}}
+	origin := map[string]string{}
+	fieldValues(original, nil, origin)
+	values := map[string]map[string]string{}
+	for _, name := range names {
+		// find the customizations before merging, since the merge modifies
+		// the consumer
+		values[name] = map[string]string{}
+		fieldValues(consumers[name], nil, values[name])
+	}
+	report.Conflicts = consumerConflicts(names, origin, values)
+
+	results := map[string]*yaml.RNode{}
+	for _, name := range names {
+		result, r, err := m.Merge(consumers[name], original.Copy(), update.Copy())
+		if err != nil {
+			return nil, nil, errors.WrapPrefixf(err, "%s", name)
+		}
+		results[name] = result
+		report.Reports[name] = r
+	}
+	return results, report, nil
+}
+
+// consumerConflicts returns the fields which the consumers changed from the
+// values in origin to different values, sorted by path.
+func consumerConflicts(names []string, origin map[string]string, values map[string]map[string]string) []ConsumerConflict {
+	paths := map[string]bool{}
+	for p := range origin {
+		paths[p] = true
+	}
+	for _, v := range values {
+		for p := range v {
+			paths[p] = true
+		}
+	}
+	var sorted []string
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var conflicts []ConsumerConflict
+	for _, p := range sorted {
+		changed := map[string]string{}
+		distinct := map[string]bool{}
+		for _, name := range names {
+			if v := values[name][p]; v != origin[p] {
+				changed[name] = v
+				distinct[v] = true
+			}
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+		conflicts = append(conflicts, ConsumerConflict{Path: p, Origin: origin[p], Values: changed})
+	}
+	return conflicts
+}
+
+// fieldValues records the value of each field of node in values, keyed by
+// path.  Maps and associative lists are recorded field by field, and other
+// values as a whole.
+func fieldValues(node *yaml.RNode, path []string, values map[string]string) {
+	if yaml.IsMissingOrNull(node) {
+		return
+	}
+	switch node.YNode().Kind {
+	case yaml.MappingNode:
+		_ = node.VisitFields(func(f *yaml.MapNode) error {
+			fieldValues(f.Value, append(path[:len(path):len(path)], f.Key.YNode().Value), values)
+			return nil
+		})
+	case yaml.SequenceNode:
+		key := node.GetAssociativeKey()
+		if key == "" {
+			values[pathString(path)] = displayValue(node)
+			return
+		}
+		for _, e := range node.Content() {
+			v := elementKeyValues(e, []string{key})
+			segment := elementSegment([]string{key}, v)
+			fieldValues(yaml.NewRNode(e), append(path[:len(path):len(path)], segment), values)
+		}
+	default:
+		values[pathString(path)] = displayValue(node)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_MergeConsumers(t *testing.T) {
+	origin := yaml.MustParse(`
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        memory: 1Gi
+`)
+	update := yaml.MustParse(`
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2.0
+        memory: 1Gi
+`)
+	consumers := map[string]*yaml.RNode{
+		"team-a": yaml.MustParse(`
+kind: Deployment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        memory: 2Gi
+`),
+		"team-b": yaml.MustParse(`
+kind: Deployment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        memory: 4Gi
+`),
+		"team-c": yaml.MustParse(`
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+`),
+	}
+
+	results, report, err := Visitor{InferAssociativeLists: true}.MergeConsumers(origin, update, consumers)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// team-a and team-b both changed replicas to 3, which is consistent, but
+	// they and team-c changed the memory to different values
+	assert.Equal(t, []ConsumerConflict{
+		{
+			Path:   "spec.template.spec.containers[name=app].memory",
+			Origin: "1Gi",
+			Values: map[string]string{"team-a": "2Gi", "team-b": "4Gi", "team-c": ""},
+		},
+	}, report.Conflicts)
+
+	if !assert.Len(t, results, 3) {
+		t.FailNow()
+	}
+	actual, err := results["team-b"].String()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Deployment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2.0
+        memory: 4Gi
+`), strings.TrimSpace(actual))
+	assert.Contains(t, report.Reports, "team-a")
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// ConvergedAdditionPolicy controls which value is kept when dest and update
+// both added a field with the same value.  The field is kept once, and is
+// neither a conflict nor an addition.
+type ConvergedAdditionPolicy int
+
+const (
+	// ConvergedTakeUpdate keeps the update value, e.g. with its comments.
+	ConvergedTakeUpdate ConvergedAdditionPolicy = iota
+
+	// ConvergedKeepDest keeps the dest value.
+	ConvergedKeepDest
+)
+
+// AddedElementPolicy controls how an associative list element which is
+// missing from origin, and which dest and update both added with the same
+// merge key values, is merged.
+type AddedElementPolicy int
+
+const (
+	// AddedElementsMerge merges the fields of the elements, so that only the
+	// fields added with different values conflict.
+	AddedElementsMerge AddedElementPolicy = iota
+
+	// AddedElementsConflict merges the elements as a whole, so that elements
+	// with different content are a single conflict on the element, which is
+	// resolved by the ConflictStrategy.
+	AddedElementsConflict
+)
+
+// ConvergedDeletionPolicy controls how a field which dest and update both
+// removed from origin is reported.
+type ConvergedDeletionPolicy int
+
+const (
+	// ConvergedDeletionsSilent removes the field without reporting it.
+	ConvergedDeletionsSilent ConvergedDeletionPolicy = iota
+
+	// ConvergedDeletionsWarn removes the field and records a warning, so
+	// that fields removed on both sides can be reviewed.
+	ConvergedDeletionsWarn
+)
+
+// convergedDeletion returns true if the field at path is in origin and was
+// removed, or set to null, in both dest and update.
+func (m Visitor) convergedDeletion(nodes walk.Sources, path []string) bool {
+	if len(path) == 0 || yaml.IsMissingOrNull(nodes.Origin()) ||
+		!yaml.IsMissingOrNull(nodes.Dest()) || !yaml.IsMissingOrNull(nodes.Updated()) {
+		return false
+	}
+	if m.ConvergedDeletions == ConvergedDeletionsWarn && m.report != nil {
+		m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+			"%s was removed by both dest and update", pathString(path)))
+	}
+	return true
+}
+
+// addedElement returns the merged element at path, and true, if it is a map
+// element which dest and update both added and AddedElements is
+// AddedElementsConflict.
+func (m Visitor) addedElement(nodes walk.Sources, path []string) (*yaml.RNode, bool, error) {
+	if m.AddedElements != AddedElementsConflict || len(path) == 0 ||
+		!strings.HasPrefix(path[len(path)-1], "[") || !yaml.IsMissingOrNull(nodes.Origin()) {
+		return nil, false, nil
+	}
+	for _, node := range []*yaml.RNode{nodes.Dest(), nodes.Updated()} {
+		if yaml.IsMissingOrNull(node) || node.YNode().Kind != yaml.MappingNode {
+			return nil, false, nil
+		}
+	}
+	node, err := m.visitAddedInBoth(nodes, path)
+	return node, true, err
+}
+
+// visitAddedInBoth merges a field which is missing from origin and was added
+// to both dest and update.
+func (m Visitor) visitAddedInBoth(nodes walk.Sources, path []string) (*yaml.RNode, error) {
+	if displayValue(nodes.Dest()) == displayValue(nodes.Updated()) {
+		// converged -- both sides added the same value
+		if m.ConvergedAdditions == ConvergedKeepDest {
+			return m.decide(path, nodes.Dest(), "kept dest because dest and update added the same value")
+		}
+		return m.decide(path, nodes.Updated(), "took update because dest and update added the same value")
+	}
+
+	// both sides added different values
+	strategy, err := m.recordConflict(path, nodes)
+	if err != nil {
+		return nil, err
+	}
+	if strategy == TakeDest {
+		return m.decide(path, nodes.Dest(), "kept dest because dest and update added different values")
+	}
+	return m.decide(path, nodes.Updated(), "took update because dest and update added different values")
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ConvergedAdditions(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+`
+	update := `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  replicas: 1
+  paused: true # update
+  selector:
+    app: foo
+  items:
+  - name: a
+    value: 1
+  args: [a, b]
+`
+	local := `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  replicas: 1
+  paused: true # local
+  selector:
+    app: foo
+  items:
+  - name: a
+    value: 1
+  args: [a, b]
+`
+
+	var testCases = []struct {
+		description string
+		policy      ConvergedAdditionPolicy
+		expected    string
+	}{
+		{
+			description: `take update`,
+			policy:      ConvergedTakeUpdate,
+			expected: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  replicas: 1
+  paused: true # update
+  selector:
+    app: foo
+  items:
+  - name: a
+    value: 1
+  args: [a, b]
+`,
+		},
+		{
+			description: `keep dest`,
+			policy:      ConvergedKeepDest,
+			expected:    local,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{ConvergedAdditions: tc.policy, InferAssociativeLists: true}.
+				MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			// the fields were added once and didn't conflict
+			assert.Empty(t, report.Conflicts)
+		})
+	}
+}
+
+func TestVisitor_ConvergedAdditions_conflict(t *testing.T) {
+	origin := `
+kind: Foo
+`
+	update := `
+kind: Foo
+replicas: 2
+`
+	local := `
+kind: Foo
+replicas: 3
+`
+
+	actual, report, err := Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "kind: Foo\nreplicas: 2", strings.TrimSpace(actual))
+	assert.Equal(t, []Conflict{
+		{Path: "replicas", Dest: "3", Update: "2"},
+	}, report.Conflicts)
+
+	actual, _, err = Visitor{ConflictStrategy: TakeDest}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "kind: Foo\nreplicas: 3", strings.TrimSpace(actual))
+}
+
+func TestVisitor_AddedElements(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+`
+	update := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:2.0
+    args: [--upstream]
+`
+	local := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:1.0
+`
+
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		expected    string
+		conflicts   []string
+	}{
+		{
+			description: `fields of the elements are merged by default`,
+			visitor:     Visitor{InferAssociativeLists: true},
+			expected: `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:2.0
+    args: [--upstream]
+`,
+			conflicts: []string{"spec.containers[name=sidecar].image"},
+		},
+		{
+			description: `the elements conflict and update is taken`,
+			visitor:     Visitor{InferAssociativeLists: true, AddedElements: AddedElementsConflict},
+			expected: `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:2.0
+    args: [--upstream]
+`,
+			conflicts: []string{"spec.containers[name=sidecar]"},
+		},
+		{
+			description: `the elements conflict and dest is kept`,
+			visitor: Visitor{
+				InferAssociativeLists: true,
+				AddedElements:         AddedElementsConflict,
+				ConflictStrategy:      TakeDest,
+			},
+			expected: `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:1.0
+`,
+			conflicts: []string{"spec.containers[name=sidecar]"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			var conflicts []string
+			for _, c := range report.Conflicts {
+				conflicts = append(conflicts, c.Path)
+			}
+			assert.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+
+	// elements added with the same content have converged
+	_, report, err := Visitor{InferAssociativeLists: true, AddedElements: AddedElementsConflict}.
+		MergeStrings(update, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestVisitor_ConvergedDeletions(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  replicas: 3
+  selector:
+    app: nginx
+  args: [--verbose]
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:1.0
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 5
+  selector: null
+  containers:
+  - name: nginx
+    image: nginx:1.7
+`
+	update := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.8
+`
+	expected := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.8
+`
+
+	testCases := []struct {
+		description string
+		visitor     Visitor
+		warnings    []string
+	}{
+		{
+			description: `the fields are removed`,
+			visitor:     Visitor{InferAssociativeLists: true},
+		},
+		{
+			description: `the fields are removed when keeping dest`,
+			visitor:     Visitor{InferAssociativeLists: true, ConflictStrategy: TakeDest},
+		},
+		{
+			description: `the removed fields are warned about`,
+			visitor:     Visitor{InferAssociativeLists: true, ConvergedDeletions: ConvergedDeletionsWarn},
+			warnings: []string{
+				"spec.args was removed by both dest and update",
+				"spec.containers[name=sidecar] was removed by both dest and update",
+				"spec.selector was removed by both dest and update",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+			assert.Empty(t, report.Conflicts)
+			assert.ElementsMatch(t, tc.warnings, report.Warnings)
+		})
+	}
+}
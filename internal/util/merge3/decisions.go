@@ -0,0 +1,121 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// DecisionSource identifies the source of a merged value.
+type DecisionSource string
+
+const (
+	// SourceDest is a value taken from dest.
+	SourceDest DecisionSource = "dest"
+
+	// SourceOrigin is a value taken from origin.
+	SourceOrigin DecisionSource = "origin"
+
+	// SourceUpdate is a value taken from update.
+	SourceUpdate DecisionSource = "update"
+
+	// SourceDeleted is a value which was removed from the result.
+	SourceDeleted DecisionSource = "deleted"
+
+	// SourceMerged is a value combined from several sources, e.g. an ordered
+	// set.  It is not replayed.
+	SourceMerged DecisionSource = "merged"
+)
+
+// Decision records the source chosen for a merged field.
+type Decision struct {
+	// Path is the path to the field.
+	Path string `json:"path"`
+
+	// Source is the source the value was taken from.
+	Source DecisionSource `json:"source"`
+}
+
+// recordDecision records the source of node, the merged value of nodes at
+// path, and returns node and err.
+func (m Visitor) recordDecision(path []string, nodes walk.Sources, node *yaml.RNode, err error) (*yaml.RNode, error) {
+	if err != nil || m.report == nil {
+		return node, err
+	}
+	if m.RecordDecisions {
+		m.report.Decisions = append(m.report.Decisions, Decision{
+			Path:   pathString(path),
+			Source: decisionSource(nodes, node),
+		})
+	}
+	if m.diff {
+		m.recordChange(path, nodes, node)
+	}
+	m.recordAudit(path, nodes, node)
+	m.checkDivergence(path, nodes, node)
+	m.classifyChange(path, nodes, node)
+	m.recordReverse(path, nodes, node)
+	return node, nil
+}
+
+// decisionSource returns which of nodes the merged node was taken from.
+func decisionSource(nodes walk.Sources, node *yaml.RNode) DecisionSource {
+	if yaml.IsMissingOrNull(node) {
+		return SourceDeleted
+	}
+	for _, s := range []struct {
+		node   *yaml.RNode
+		source DecisionSource
+	}{
+		{nodes.Dest(), SourceDest},
+		{nodes.Updated(), SourceUpdate},
+		{nodes.Origin(), SourceOrigin},
+	} {
+		if s.node != nil && s.node.YNode() == node.YNode() {
+			return s.source
+		}
+	}
+	return SourceMerged
+}
+
+// replayDecision returns the value recorded for path by a previous merge, and
+// true if there is one.
+func (m Visitor) replayDecision(nodes walk.Sources, path []string) (*yaml.RNode, bool) {
+	switch m.replay[pathString(path)] {
+	case SourceDest:
+		return nodes.Dest(), true
+	case SourceOrigin:
+		return nodes.Origin(), true
+	case SourceUpdate:
+		return nodes.Updated(), true
+	case SourceDeleted:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// replayIndex indexes decisions by path.
+func replayIndex(decisions []Decision) map[string]DecisionSource {
+	if len(decisions) == 0 {
+		return nil
+	}
+	index := make(map[string]DecisionSource, len(decisions))
+	for _, d := range decisions {
+		index[d.Path] = d.Source
+	}
+	return index
+}
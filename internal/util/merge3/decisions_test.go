@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_RecordDecisions(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.7
+  paused: false
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.8
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 3
+  image: nginx:1.7
+  paused: false
+`
+
+	actual, report, err := Visitor{RecordDecisions: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+spec:
+  replicas: 3
+  image: nginx:1.8
+`), strings.TrimSpace(actual))
+	assert.ElementsMatch(t, []Decision{
+		{Path: "kind", Source: SourceDest},
+		{Path: "spec.image", Source: SourceUpdate},
+		{Path: "spec.paused", Source: SourceDeleted},
+		{Path: "spec.replicas", Source: SourceDest},
+	}, report.Decisions)
+
+	// decisions are serializable
+	b, err := json.Marshal(report.Decisions)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var decisions []Decision
+	if !assert.NoError(t, json.Unmarshal(b, &decisions)) {
+		t.FailNow()
+	}
+
+	// the inputs change slightly -- without replay the update now changes
+	// replicas and restores paused
+	update = `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.9
+  paused: false
+`
+	actual, _, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.9
+  paused: false
+`), strings.TrimSpace(actual))
+
+	// replaying the decisions takes each field from the same source
+	actual, report, err = Visitor{Replay: decisions, RecordDecisions: true}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+spec:
+  replicas: 3
+  image: nginx:1.9
+`), strings.TrimSpace(actual))
+	assert.ElementsMatch(t, decisions, report.Decisions)
+}
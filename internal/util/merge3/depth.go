@@ -0,0 +1,22 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+// belowMaxDepth returns true if path is nested deeper than MaxMergeDepth, and
+// should be kept from dest.  Each field and associative list element in path
+// is one level.
+func (m Visitor) belowMaxDepth(path []string) bool {
+	return m.MaxMergeDepth > 0 && len(path) > m.MaxMergeDepth
+}
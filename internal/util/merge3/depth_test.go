@@ -0,0 +1,106 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_MaxMergeDepth(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  template:
+    image: nginx:1.7
+    args: [a]
+`
+	update := `
+kind: Bar
+spec:
+  replicas: 2
+  paused: true
+  template:
+    image: nginx:1.8
+    args: [b]
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 1
+  template:
+    image: nginx:1.7
+    args: [a, c]
+`
+
+	var testCases = []struct {
+		description string
+		depth       int
+		expected    string
+	}{
+		{
+			description: `no limit`,
+			expected: `
+kind: Bar
+spec:
+  replicas: 2
+  template:
+    image: nginx:1.8
+    args: [b]
+  paused: true
+`,
+		},
+		{
+			description: `merge the top level`,
+			depth:       1,
+			expected: `
+kind: Bar
+spec:
+  replicas: 1
+  template:
+    image: nginx:1.7
+    args: [a, c]
+`,
+		},
+		{
+			description: `merge two levels`,
+			depth:       2,
+			expected: `
+kind: Bar
+spec:
+  replicas: 2
+  template:
+    image: nginx:1.7
+    args: [a, c]
+  paused: true
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{MaxMergeDepth: tc.depth}.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// description returns the head comment describing a field with the given
+// keys, which is the head comment of the update key if AdoptDescriptions is
+// set and update has one, and otherwise the merged comment.
+func (m Visitor) description(keys walk.Sources, comment string) string {
+	if !m.AdoptDescriptions || len(keys) <= walk.UpdatedIndex {
+		return comment
+	}
+	if update := keys.Updated(); !yaml.IsMissingOrNull(update) && update.YNode().HeadComment != "" {
+		return update.YNode().HeadComment
+	}
+	return comment
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_AdoptDescriptions(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		adopt       bool
+	}{
+		{
+			description: `improved description is adopted onto the dest value`,
+			origin: `
+spec:
+  # number of replicas
+  replicas: 1`,
+			update: `
+spec:
+  # The number of pods to run.  Set to at least 3 for high availability.
+  replicas: 1`,
+			local: `
+spec:
+  # replicas for my env
+  replicas: 5`,
+			expected: `
+spec:
+  # The number of pods to run.  Set to at least 3 for high availability.
+  replicas: 5`,
+			adopt: true,
+		},
+		{
+			description: `dest description is kept without the option`,
+			origin: `
+spec:
+  # number of replicas
+  replicas: 1`,
+			update: `
+spec:
+  # The number of pods to run.  Set to at least 3 for high availability.
+  replicas: 1`,
+			local: `
+spec:
+  # replicas for my env
+  replicas: 5`,
+			expected: `
+spec:
+  # replicas for my env
+  replicas: 5`,
+		},
+		{
+			description: `description of a map field is adopted`,
+			origin: `
+spec:
+  template:
+    foo: bar`,
+			update: `
+spec:
+  # The pod template.
+  template:
+    foo: bar`,
+			local: `
+spec:
+  # local template
+  template:
+    foo: baz`,
+			expected: `
+spec:
+  # The pod template.
+  template:
+    foo: baz`,
+			adopt: true,
+		},
+		{
+			description: `dest description is kept if update has none`,
+			origin: `
+spec:
+  replicas: 1`,
+			update: `
+spec:
+  replicas: 1`,
+			local: `
+spec:
+  # replicas for my env
+  replicas: 5`,
+			expected: `
+spec:
+  # replicas for my env
+  replicas: 5`,
+			adopt: true,
+		},
+		{
+			description: `line comments are merged as usual`,
+			origin: `
+spec:
+  replicas: 1 # origin`,
+			update: `
+spec:
+  # The number of pods to run.
+  replicas: 1 # update`,
+			local: `
+spec:
+  replicas: 5 # local`,
+			expected: `
+spec:
+  # The number of pods to run.
+  replicas: 5 # local`,
+			adopt: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{AdoptDescriptions: tc.adopt}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
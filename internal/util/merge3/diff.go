@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// change is a field whose merged value differs from dest, or which
+// conflicted.
+type change struct {
+	path                         string
+	origin, dest, update, result string
+	conflict                     bool
+}
+
+// Diff merges dest, origin and update and returns a three-way diff of the
+// merge formatted for display in a terminal.  Each field which the merge
+// changed in dest, or which conflicted, is shown on a line with its origin,
+// dest and update values and the merged result.
+func Diff(origin, update, dest string) (string, error) {
+	_, report, err := Visitor{diff: true}.MergeStrings(dest, origin, update)
+	if err != nil {
+		return "", err
+	}
+	return formatChanges(report.changes)
+}
+
+// recordChange records the field at path if its merged value node differs
+// from dest or it conflicted.
+func (m Visitor) recordChange(path []string, nodes walk.Sources, node *yaml.RNode) {
+	p := pathString(path)
+	c := change{
+		path:   p,
+		origin: displayValue(nodes.Origin()),
+		dest:   displayValue(nodes.Dest()),
+		update: displayValue(nodes.Updated()),
+		result: displayValue(node),
+	}
+	c.conflict = m.report.conflictPaths[p]
+	if c.result == c.dest && !c.conflict {
+		return
+	}
+	m.report.changes = append(m.report.changes, c)
+}
+
+// formatChanges formats the changes as a table.
+func formatChanges(changes []change) (string, error) {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tORIGIN\tDEST\tUPDATE\tRESULT\tSTATUS")
+	for _, c := range changes {
+		status := "changed"
+		if c.conflict {
+			status = "conflict"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.path, orNone(c.origin), orNone(c.dest), orNone(c.update), orNone(c.result), status)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// orNone returns value, or `-` if value is empty.
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	origin := `
+kind: Foo
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [a]
+  paused: false
+`
+	update := `
+kind: Foo
+metadata:
+  name: foo
+spec:
+  replicas: 2
+  image: nginx:1.8
+  args: [b]
+  minReadySeconds: 10
+`
+	local := `
+kind: Foo
+metadata:
+  name: foo
+  labels:
+    team: local
+spec:
+  replicas: 3
+  image: nginx:1.7
+  args: [b]
+  paused: false
+`
+
+	actual, err := Diff(origin, update, local)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	golden := filepath.Join("testdata", "diff.txt")
+	// If KPT_GENERATE_MERGE3_TEST_GOLDEN_FILE env is set, update the golden
+	// files.
+	if os.Getenv("KPT_GENERATE_MERGE3_TEST_GOLDEN_FILE") != "" {
+		if err := ioutil.WriteFile(golden, []byte(actual), 0666); err != nil {
+			t.Errorf("error writing golden output file: %v", err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, string(expected), actual)
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// checkDivergence records a warning if the merged node at path kept a dest
+// value which differs from origin, while update has the origin value.
+func (m Visitor) checkDivergence(path []string, nodes walk.Sources, node *yaml.RNode) {
+	if !m.WarnOnDivergence || decisionSource(nodes, node) != SourceDest ||
+		yaml.IsMissingOrNull(nodes.Origin()) || yaml.IsMissingOrNull(nodes.Updated()) {
+		return
+	}
+	origin, update, dest := displayValue(nodes.Origin()), displayValue(nodes.Updated()), displayValue(node)
+	if origin != update || dest == origin {
+		return
+	}
+	m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+		"%s keeps the local value %q, while update has the origin value %q",
+		pathString(path), dest, origin))
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_WarnOnDivergence(t *testing.T) {
+	// upstream reverted the image and replicas to their origin values, which
+	// dest changed
+	origin := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [a]
+  paused: false
+`
+	update := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [a]
+  paused: true
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 3
+  image: nginx:1.8
+  args: [a, b]
+  paused: false
+  extra: local
+`
+	expected := `
+kind: Deployment
+spec:
+  replicas: 3
+  image: nginx:1.8
+  args: [a, b]
+  paused: true
+  extra: local
+`
+
+	actual, report, err := Visitor{WarnOnDivergence: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+	assert.Equal(t, []string{
+		`spec.args keeps the local value "[a, b]", while update has the origin value "[a]"`,
+		`spec.image keeps the local value "nginx:1.8", while update has the origin value "nginx:1.7"`,
+		`spec.replicas keeps the local value "3", while update has the origin value "1"`,
+	}, report.Warnings)
+
+	// warnings are only recorded when requested
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.Warnings)
+}
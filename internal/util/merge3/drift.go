@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// Drift measures how much dest has been customized from origin.  Only leaf
+// fields (scalars and lists which are not merged by element) are counted.
+type Drift struct {
+	// Fields is the number of fields present in origin or dest.
+	Fields int `json:"fields"`
+
+	// Changed is the number of Fields where dest differs from origin.
+	Changed int `json:"changed"`
+}
+
+// Fraction returns the fraction of Fields which were Changed.
+func (d Drift) Fraction() float64 {
+	if d.Fields == 0 {
+		return 0
+	}
+	return float64(d.Changed) / float64(d.Fields)
+}
+
+// recordDrift counts the leaf field in the drift metric, if the drift is
+// measured.
+func (m Visitor) recordDrift(nodes walk.Sources) error {
+	if m.report == nil || (m.MaxDrift <= 0 && !m.ReportDrift) {
+		return nil
+	}
+	origin, dest := yaml.IsMissingOrNull(nodes.Origin()), yaml.IsMissingOrNull(nodes.Dest())
+	if origin && dest {
+		return nil
+	}
+	m.report.Drift.Fields++
+	if origin != dest {
+		m.report.Drift.Changed++
+		return nil
+	}
+	values, err := m.getStrValues(walk.Sources{nodes.Dest(), nodes.Origin(), nil})
+	if err != nil {
+		return err
+	}
+	if values.Dest != values.Origin {
+		m.report.Drift.Changed++
+	}
+	return nil
+}
+
+// checkDrift records a warning if the drift exceeds MaxDrift.
+func (m Visitor) checkDrift() {
+	d := m.report.Drift
+	if m.MaxDrift <= 0 || d.Fraction() <= m.MaxDrift {
+		return
+	}
+	m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+		"dest differs from origin in %d of %d fields (%.0f%%), more than the maximum of %.0f%%",
+		d.Changed, d.Fields, 100*d.Fraction(), 100*m.MaxDrift))
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_MaxDrift(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [a]
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 1
+  image: nginx:1.8
+  args: [a]
+`
+
+	var testCases = []struct {
+		description string
+		local       string
+		maxDrift    float64
+		drift       Drift
+		warnings    []string
+	}{
+		{
+			description: `unchanged dest`,
+			local:       origin,
+			maxDrift:    0.5,
+			drift:       Drift{Fields: 4},
+		},
+		{
+			description: `low drift`,
+			local: `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx:1.7
+  args: [a]
+`,
+			maxDrift: 0.5,
+			drift:    Drift{Fields: 4, Changed: 1},
+		},
+		{
+			description: `high drift`,
+			local: `
+kind: Foo
+spec:
+  replicas: 2
+  args: [b]
+  paused: true
+`,
+			maxDrift: 0.5,
+			drift:    Drift{Fields: 5, Changed: 4},
+			warnings: []string{
+				"dest differs from origin in 4 of 5 fields (80%), more than the maximum of 50%"},
+		},
+		{
+			description: `high drift without a maximum`,
+			local: `
+kind: Foo
+spec:
+  replicas: 2
+  args: [b]
+  paused: true
+`,
+			drift: Drift{Fields: 5, Changed: 4},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			_, report, err := Visitor{MaxDrift: tc.maxDrift, ReportDrift: tc.maxDrift == 0}.
+				MergeStrings(tc.local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.drift, report.Drift)
+			assert.Equal(t, tc.warnings, report.Warnings)
+		})
+	}
+
+	// the drift isn't measured unless it is requested
+	_, report, err := Visitor{}.MergeStrings(update, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, Drift{}, report.Drift)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DuplicateKeyPolicy controls how duplicate map keys in the sources are
+// handled.  YAML forbids duplicate keys, but the parser accepts them.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysError fails the merge if any source contains a map with
+	// duplicate keys.
+	DuplicateKeysError DuplicateKeyPolicy = iota
+
+	// DuplicateKeysKeepFirst keeps the first occurrence of a duplicate key.
+	DuplicateKeysKeepFirst
+
+	// DuplicateKeysKeepLast keeps the last occurrence of a duplicate key.
+	DuplicateKeysKeepLast
+)
+
+// handleDuplicateKeys applies the DuplicateKeys policy to each source.
+func (m Visitor) handleDuplicateKeys(dest, original, update *yaml.RNode) error {
+	sources := []struct {
+		name string
+		node *yaml.RNode
+	}{{"dest", dest}, {"origin", original}, {"update", update}}
+	for _, s := range sources {
+		if s.node.IsNil() {
+			continue
+		}
+		if err := m.DuplicateKeys.apply(s.node.YNode()); err != nil {
+			err.Message = s.name + ": " + err.Message
+			return err
+		}
+	}
+	return nil
+}
+
+// apply recursively removes the duplicate keys from node according to the
+// policy, or returns an error if the policy is DuplicateKeysError.
+func (p DuplicateKeyPolicy) apply(node *yaml.Node) *Error {
+	if node.Kind == yaml.MappingNode {
+		var content []*yaml.Node
+		index := map[string]int{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			j, found := index[key.Value]
+			switch {
+			case !found:
+				index[key.Value] = len(content)
+				content = append(content, key, value)
+			case p == DuplicateKeysKeepLast:
+				content[j], content[j+1] = key, value
+			case p == DuplicateKeysKeepFirst:
+			default:
+				return &Error{
+					Kind:    ErrorKindDuplicateKey,
+					Message: fmt.Sprintf("duplicate key %q at line %d", key.Value, key.Line),
+				}
+			}
+		}
+		node.Content = content
+	}
+	for i := range node.Content {
+		if err := p.apply(node.Content[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
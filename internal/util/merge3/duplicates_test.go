@@ -0,0 +1,93 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_DuplicateKeys(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  a: 1
+`
+	update := `
+kind: Foo
+spec:
+  a: 1
+  b: 2
+`
+	local := `
+kind: Foo
+spec:
+  a: 1
+  c: first
+  c: last
+`
+
+	var testCases = []struct {
+		description string
+		policy      DuplicateKeyPolicy
+		expected    string
+		err         string
+	}{
+		{
+			description: `duplicate keys error by default`,
+			err:         `dest: duplicate key "c" at line 6`,
+		},
+		{
+			description: `keep the first duplicate key`,
+			policy:      DuplicateKeysKeepFirst,
+			expected: `
+kind: Foo
+spec:
+  a: 1
+  c: first
+  b: 2
+`,
+		},
+		{
+			description: `keep the last duplicate key`,
+			policy:      DuplicateKeysKeepLast,
+			expected: `
+kind: Foo
+spec:
+  a: 1
+  c: last
+  b: 2
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{DuplicateKeys: tc.policy}.MergeStrings(local, origin, update)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_emptyStringKeys(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		conflicts   []string
+	}{
+		{
+			description: `empty-string key with a scalar value`,
+			origin: `
+data:
+  "": origin
+  a: origin`,
+			update: `
+data:
+  "": update
+  a: origin`,
+			local: `
+data:
+  "": origin
+  a: local`,
+			expected: `
+data:
+  "": update
+  a: local`,
+		},
+		{
+			description: `empty-string key with a map value`,
+			origin: `
+"":
+  a: origin
+  b: origin`,
+			update: `
+"":
+  a: update
+  b: origin`,
+			local: `
+"":
+  a: origin
+  b: local`,
+			expected: `
+"":
+  a: update
+  b: local`,
+		},
+		{
+			description: `empty-string key added in update`,
+			origin: `
+data:
+  a: origin`,
+			update: `
+data:
+  '': update
+  a: origin`,
+			local: `
+data:
+  a: origin`,
+			expected: `
+data:
+  a: origin
+  "": update`,
+		},
+		{
+			description: `empty-string key removed in update`,
+			origin: `
+data:
+  "": origin
+  a: origin`,
+			update: `
+data:
+  a: origin`,
+			local: `
+data:
+  "": origin
+  a: origin`,
+			expected: `
+data:
+  a: origin`,
+		},
+		{
+			description: `conflicting empty-string key`,
+			origin: `
+data:
+  "": origin`,
+			update: `
+data:
+  "": update`,
+			local: `
+data:
+  "": local`,
+			expected: `
+data:
+  "": update`,
+			conflicts: []string{`data.""`},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{}.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			var conflicts []string
+			for _, c := range report.Conflicts {
+				conflicts = append(conflicts, c.Path)
+			}
+			assert.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"encoding/json"
+)
+
+// ErrorKind identifies the kind of an Error.
+type ErrorKind string
+
+const (
+	// ErrorKindListGrowth is returned when an associative list gains more
+	// elements than MaxListGrowth.
+	ErrorKindListGrowth ErrorKind = "list-growth"
+
+	// ErrorKindDuplicateKey is returned when a source contains a map with
+	// duplicate keys.
+	ErrorKindDuplicateKey ErrorKind = "duplicate-key"
+
+	// ErrorKindDuplicateMergeKey is returned when a merged associative list
+	// contains more than one element with the same merge key values.
+	ErrorKindDuplicateMergeKey ErrorKind = "duplicate-merge-key"
+
+	// ErrorKindAdditionsPerKey is returned when a merge adds more than
+	// MaxAdditionsPerKey elements with the same merge key value to a list.
+	ErrorKindAdditionsPerKey ErrorKind = "additions-per-key"
+
+	// ErrorKindMissingMergeKey is returned when an associative list has
+	// elements without the merge key, and KeylessElements is
+	// KeylessElementsError.
+	ErrorKindMissingMergeKey ErrorKind = "missing-merge-key"
+
+	// ErrorKindInvalidOutput is returned when the merged output doesn't
+	// parse back to the merged result, and VerifyOutput is set.
+	ErrorKindInvalidOutput ErrorKind = "invalid-output"
+
+	// ErrorKindUnknownField is returned when a resource has a top-level key
+	// which isn't in its schema, and StrictTopLevelKeys is set.
+	ErrorKindUnknownField ErrorKind = "unknown-field"
+
+	// ErrorKindLineage is returned when a merged value can't be traced to
+	// any of the sources, and ValidateLineage is set.
+	ErrorKindLineage ErrorKind = "lineage"
+
+	// ErrorKindImmutableField is returned when update changes one of the
+	// ImmutableFields of a resource, and ImmutableFieldChanges is
+	// ImmutableFieldsError.
+	ErrorKindImmutableField ErrorKind = "immutable-field"
+
+	// ErrorKindCrossDocumentAlias is returned when an alias in a
+	// multi-document stream references an anchor in another document, and
+	// CrossDocumentAliases is CrossDocumentAliasesError.
+	ErrorKindCrossDocumentAlias ErrorKind = "cross-document-alias"
+
+	// ErrorKindEmptyUpdate is returned when update contains no resources
+	// but origin does, and EmptyUpdate is EmptyUpdateError.
+	ErrorKindEmptyUpdate ErrorKind = "empty-update"
+)
+
+// Error is returned when a merge fails.  It can be serialized as JSON so
+// that CI systems can parse it.
+type Error struct {
+	// Path is the path to the field which caused the error, if known.
+	Path string `json:"path,omitempty"`
+
+	// Kind identifies the kind of error.
+	Kind ErrorKind `json:"kind"`
+
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// MarshalJSON returns the Error as a JSON object.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	type plain Error
+	return json.Marshal((*plain)(e))
+}
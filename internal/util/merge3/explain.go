@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Explain merges dest, origin and update and returns a human-readable
+// explanation of how the merged value at path was chosen, e.g.
+// "kept dest because update==origin".
+//
+// Associative list elements are identified in path by their merge key,
+// e.g. []string{"spec", "containers", "[name=nginx]", "image"}.
+func Explain(origin, update, dest string, path []string) (string, error) {
+	_, report, err := Visitor{explain: true}.MergeStrings(dest, origin, update)
+	if err != nil {
+		return "", err
+	}
+	explanation, found := report.explanations[pathString(path)]
+	if !found {
+		return "", errors.Errorf("no value was merged for path %s", pathString(path))
+	}
+	return explanation, nil
+}
+
+// decide records the reason node was chosen as the merged value for path,
+// and returns node.
+func (m Visitor) decide(path []string, node *yaml.RNode, reason string) (*yaml.RNode, error) {
+	if m.report != nil && m.report.explanations != nil {
+		m.report.explanations[pathString(path)] = reason
+	}
+	return node, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+`
+	update := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  paused: true
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+`
+
+	var testCases = []struct {
+		path     []string
+		expected string
+		err      string
+	}{
+		{
+			path:     []string{"spec", "replicas"},
+			expected: "kept dest because update==origin",
+		},
+		{
+			path:     []string{"spec", "paused"},
+			expected: "took update because update added it",
+		},
+		{
+			path:     []string{"spec", "template", "spec", "containers", "[name=nginx]", "image"},
+			expected: "took update because dest==origin and update!=origin",
+		},
+		{
+			path:     []string{"spec", "template"},
+			expected: "merged fields into the dest map",
+		},
+		{
+			path: []string{"spec", "missing"},
+			err:  "no value was merged for path spec.missing",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(strings.Join(tc.path, "."), func(t *testing.T) {
+			actual, err := Explain(origin, update, local, tc.path)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// fingerprint returns a hash of the paths in changes which the merge
+// changed, along with their ChangeKind.  The hash doesn't depend on the
+// values of the fields, so resources which received the same changes from
+// different packages have the same fingerprint.
+func fingerprint(changes map[string]ChangeKind) string {
+	var lines []string
+	for p, kind := range changes {
+		if kind == ChangeNoop {
+			continue
+		}
+		lines = append(lines, p+" "+string(kind))
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
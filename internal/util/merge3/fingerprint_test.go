@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Fingerprints(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data:
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: c
+data:
+  value: origin
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update-a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+data:
+  value: update-b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: c
+data:
+  value: origin
+  other: added
+`
+
+	r := Registry{Fingerprints: true}
+	_, reports, err := r.MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	a := reports["v1/ConfigMap//a"]
+	b := reports["v1/ConfigMap//b"]
+	c := reports["v1/ConfigMap//c"]
+	if !assert.NotEmpty(t, a.Fingerprint) {
+		t.FailNow()
+	}
+	assert.Equal(t, a.Fingerprint, b.Fingerprint, "identical change sets")
+	assert.NotEqual(t, a.Fingerprint, c.Fingerprint, "different change sets")
+	assert.Nil(t, a.Changes)
+
+	_, again, err := r.MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, a.Fingerprint, again["v1/ConfigMap//a"].Fingerprint, "stable across merges")
+	assert.Equal(t, c.Fingerprint, again["v1/ConfigMap//c"].Fingerprint, "stable across merges")
+
+	_, unset, err := Registry{}.MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, unset["v1/ConfigMap//a"].Fingerprint)
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// checkListGrowth verifies the associative list at path did not gain more
+// than MaxListGrowth elements while being merged.
+func (m Visitor) checkListGrowth(path []string, before, after int) error {
+	if m.MaxListGrowth <= 0 || after-before <= m.MaxListGrowth {
+		return nil
+	}
+	msg := fmt.Sprintf("list %s gained %d elements, more than the maximum of %d",
+		pathString(path), after-before, m.MaxListGrowth)
+	if !m.WarnOnMaxListGrowth {
+		return &Error{Path: pathString(path), Kind: ErrorKindListGrowth, Message: msg}
+	}
+	if m.report != nil {
+		m.report.Warnings = append(m.report.Warnings, msg)
+	}
+	return nil
+}
+
+// checkAdditionsPerKey verifies the merge did not add more than
+// MaxAdditionsPerKey elements with the same value for the first merge key to
+// the associative list at path.  before contains the dest elements before
+// the merge.
+func (m Visitor) checkAdditionsPerKey(path []string, keys []string, before []*yaml.Node, after *yaml.RNode) error {
+	if m.MaxAdditionsPerKey <= 0 || len(keys) == 0 {
+		return nil
+	}
+	added := map[string]int{}
+	for _, elem := range after.Content() {
+		added[elementKeyValues(elem, keys[:1])[0]]++
+	}
+	for _, elem := range before {
+		added[elementKeyValues(elem, keys[:1])[0]]--
+	}
+	for _, elem := range after.Content() {
+		value := elementKeyValues(elem, keys[:1])[0]
+		if added[value] > m.MaxAdditionsPerKey {
+			return &Error{
+				Path: pathString(path),
+				Kind: ErrorKindAdditionsPerKey,
+				Message: fmt.Sprintf("list %s gained %d elements with %s, more than the maximum of %d",
+					pathString(path), added[value], elementSegment(keys[:1], []string{value}), m.MaxAdditionsPerKey),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_MaxListGrowth(t *testing.T) {
+	origin := `
+kind: Foo
+items:
+- name: a
+`
+	update := `
+kind: Foo
+items:
+- name: a
+- name: b
+- name: c
+- name: d
+`
+	local := `
+kind: Foo
+items:
+- name: a
+`
+
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		err         string
+		warnings    []string
+	}{
+		{
+			description: `growth within the limit`,
+			visitor:     Visitor{InferAssociativeLists: true, MaxListGrowth: 3},
+		},
+		{
+			description: `growth past the limit errors`,
+			visitor:     Visitor{InferAssociativeLists: true, MaxListGrowth: 2},
+			err:         "list items gained 3 elements, more than the maximum of 2",
+		},
+		{
+			description: `growth past the limit warns`,
+			visitor: Visitor{
+				InferAssociativeLists: true, MaxListGrowth: 2, WarnOnMaxListGrowth: true},
+			warnings: []string{"list items gained 3 elements, more than the maximum of 2"},
+		},
+		{
+			description: `no limit`,
+			visitor:     Visitor{InferAssociativeLists: true},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			_, report, err := tc.visitor.MergeStrings(local, origin, update)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.warnings, report.Warnings)
+		})
+	}
+}
+
+func TestVisitor_MaxAdditionsPerKey(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+    protocol: TCP
+`
+	update := `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+    protocol: TCP
+  - port: 80
+    protocol: UDP
+  - port: 80
+    protocol: SCTP
+  - port: 443
+    protocol: TCP
+`
+	local := origin
+
+	var testCases = []struct {
+		description string
+		max         int
+		err         string
+	}{
+		{
+			description: `additions within the limit`,
+			max:         2,
+		},
+		{
+			description: `repeated additions past the limit error`,
+			max:         1,
+			err:         "list spec.ports gained 2 elements with [port=80], more than the maximum of 1",
+		},
+		{
+			description: `no limit`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			_, _, err := Visitor{MaxAdditionsPerKey: tc.max}.MergeStrings(local, origin, update)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// MergeAndHash merges the changes between original and update into dest like
+// Merge, and also returns the content hash of the merged result, so that
+// callers can cache results and skip writing results which didn't change.
+func (m Visitor) MergeAndHash(dest, original, update *yaml.RNode) (*yaml.RNode, string, *Report, error) {
+	result, report, err := m.Merge(dest, original, update)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	hash, err := Hash(result)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return result, hash, report, nil
+}
+
+// Hash returns the hex-encoded SHA-256 of the content of node.  The hash is
+// computed over the JSON form of node, with sorted keys and without comments,
+// styles or formatting, so that equal content has the same hash however it
+// is written.  Non-finite floats, which JSON can't represent, are written
+// like `.inf` and `.nan`.  Nil nodes hash like `null`.  Nodes of any kind may
+// be hashed, e.g. the value of a single field.
+func Hash(node *yaml.RNode) (string, error) {
+	var value interface{}
+	if !yaml.IsMissingOrNull(node) {
+		// decode rather than use MarshalJSON, which only supports maps
+		if err := node.YNode().Decode(&value); err != nil {
+			return "", err
+		}
+	}
+	var b bytes.Buffer
+	if err := writeHashValue(&b, value); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeHashValue writes the decoded value to b as JSON with sorted keys,
+// except for non-finite floats which are written by canonicalFloat.
+func writeHashValue(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			b.WriteString(canonicalFloat(v))
+			return nil
+		}
+	case []interface{}:
+		b.WriteByte('[')
+		for i := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeHashValue(b, v[i]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+		return nil
+	case map[string]interface{}:
+		return writeHashFields(b, v)
+	case map[interface{}]interface{}:
+		// keys which aren't strings are written like json writes the keys
+		// of maps of numbers
+		fields := map[string]interface{}{}
+		for key, value := range v {
+			fields[fmt.Sprint(key)] = value
+		}
+		return writeHashFields(b, fields)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
+
+// writeHashFields writes the fields of a map to b as a JSON object with
+// sorted keys.
+func writeHashFields(b *bytes.Buffer, fields map[string]interface{}) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		data, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte(':')
+		if err := writeHashValue(b, fields[key]); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_MergeAndHash(t *testing.T) {
+	merge := func(local, origin, update string) string {
+		_, hash, _, err := Visitor{}.MergeAndHash(
+			yaml.MustParse(local), yaml.MustParse(origin), yaml.MustParse(update))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return hash
+	}
+
+	hash := merge(`
+kind: Foo
+spec:
+  replicas: 3
+  args: [a, b]
+`, `
+kind: Foo
+spec:
+  replicas: 1
+`, `
+kind: Foo
+spec:
+  replicas: 3
+`)
+
+	// the same content, formatted and ordered differently
+	assert.Equal(t, hash, merge(`
+# a comment
+spec:
+  args:
+  - "a"
+  - 'b'
+  replicas: 3 # another comment
+kind: Foo
+`, `
+kind: Foo
+spec:
+  replicas: 1
+`, `
+kind: Foo
+spec:
+  replicas: 3
+`))
+
+	// different content
+	assert.NotEqual(t, hash, merge(`
+kind: Foo
+spec:
+  replicas: 3
+  args: [a, c]
+`, `
+kind: Foo
+spec:
+  replicas: 1
+`, `
+kind: Foo
+spec:
+  replicas: 3
+`))
+}
+
+func TestHash(t *testing.T) {
+	var testCases = []struct {
+		description string
+		a           string
+		b           string
+		equal       bool
+	}{
+		{
+			description: `flow and block style`,
+			a:           `{a: [1, 2], b: {c: d}}`,
+			b:           "b:\n  c: d\na:\n- 1\n- 2\n",
+			equal:       true,
+		},
+		{
+			description: `quoted strings`,
+			a:           `a: "b"`,
+			b:           `a: b`,
+			equal:       true,
+		},
+		{
+			description: `strings and numbers`,
+			a:           `a: "1"`,
+			b:           `a: 1`,
+		},
+		{
+			description: `list order`,
+			a:           `a: [1, 2]`,
+			b:           `a: [2, 1]`,
+		},
+		{
+			description: `quoted scalars`,
+			a:           `"b"`,
+			b:           `b`,
+			equal:       true,
+		},
+		{
+			description: `lists`,
+			a:           `[1, 2]`,
+			b:           "- 1\n- 2\n",
+			equal:       true,
+		},
+		{
+			description: `infinite floats`,
+			a:           `a: .inf`,
+			b:           `a: .Inf`,
+			equal:       true,
+		},
+		{
+			description: `infinite floats and strings`,
+			a:           `a: -.inf`,
+			b:           `a: "-.inf"`,
+		},
+		{
+			description: `infinite floats of different signs`,
+			a:           `a: [.inf]`,
+			b:           `a: [-.inf]`,
+		},
+		{
+			description: `not a number`,
+			a:           `{a: .nan, b: 1}`,
+			b:           "b: 1\na: .NaN\n",
+			equal:       true,
+		},
+		{
+			description: `non-string keys`,
+			a:           `{1: a, true: b}`,
+			b:           "true: b\n1: a\n",
+			equal:       true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			a, err := Hash(yaml.MustParse(tc.a))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			b, err := Hash(yaml.MustParse(tc.b))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.equal, a == b)
+		})
+	}
+}
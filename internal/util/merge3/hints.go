@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+const (
+	// mergeKeyHint is the comment prefix declaring the merge key of a list,
+	// e.g. `# merge-key: name`.
+	mergeKeyHint = "merge-key:"
+
+	// atomicHint is the comment declaring that a field is replaced as a
+	// whole rather than merged, e.g. `# atomic`.
+	atomicHint = "atomic"
+)
+
+// hints are the merge semantics declared by the package author in the head
+// comment of a field.
+type hints struct {
+	// mergeKey is the merge key of the list elements.
+	mergeKey string
+
+	// atomic is true if the field is replaced as a whole.
+	atomic bool
+}
+
+// parseHints returns the hints from the head comments on a field's keys.
+// Hints in update take precedence, since they are declared by the upstream
+// package author.
+func parseHints(keys walk.Sources) hints {
+	h := hints{}
+	for _, i := range []int{walk.UpdatedIndex, walk.DestIndex, walk.OriginIndex} {
+		if i >= len(keys) || yaml.IsMissingOrNull(keys[i]) {
+			continue
+		}
+		for _, line := range strings.Split(keys[i].YNode().HeadComment, "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+			switch {
+			case line == atomicHint:
+				h.atomic = true
+			case strings.HasPrefix(line, mergeKeyHint):
+				h.mergeKey = strings.TrimSpace(strings.TrimPrefix(line, mergeKeyHint))
+			}
+		}
+		if h.atomic || h.mergeKey != "" {
+			return h
+		}
+	}
+	return h
+}
+
+// schema returns a schema implementing the hints, or nil if the hints don't
+// affect the schema.
+func (h hints) schema() *openapi.ResourceSchema {
+	if h.mergeKey == "" {
+		return nil
+	}
+	s := spec.Schema{}
+	s.Type = spec.StringOrArray{"array"}
+	s.Extensions = spec.Extensions{
+		"x-kubernetes-patch-strategy":  "merge",
+		"x-kubernetes-patch-merge-key": h.mergeKey,
+	}
+	return &openapi.ResourceSchema{Schema: &s}
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_inlineHints(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+	}{
+		{
+			description: `merge-key hint keys a list`,
+			origin: `
+kind: Foo
+# merge-key: id
+items:
+- id: a
+  value: 1`,
+			update: `
+kind: Foo
+# merge-key: id
+items:
+- id: a
+  value: 2`,
+			local: `
+kind: Foo
+# merge-key: id
+items:
+- id: a
+  value: 1
+- id: b
+  value: 1`,
+			expected: `
+kind: Foo
+# merge-key: id
+items:
+- id: a
+  value: 2
+- id: b
+  value: 1`,
+		},
+		{
+			description: `list without a hint is replaced`,
+			origin: `
+kind: Foo
+items:
+- id: a
+  value: 1`,
+			update: `
+kind: Foo
+items:
+- id: a
+  value: 2`,
+			local: `
+kind: Foo
+items:
+- id: a
+  value: 1
+- id: b
+  value: 1`,
+			expected: `
+kind: Foo
+items:
+- id: a
+  value: 2`,
+		},
+		{
+			description: `atomic hint replaces a map`,
+			origin: `
+kind: Foo
+# atomic
+selector:
+  app: foo`,
+			update: `
+kind: Foo
+# atomic
+selector:
+  app: bar`,
+			local: `
+kind: Foo
+# atomic
+selector:
+  app: foo
+  tier: web`,
+			expected: `
+kind: Foo
+# atomic
+selector:
+  app: bar`,
+		},
+		{
+			description: `atomic map unchanged in update keeps dest`,
+			origin: `
+kind: Foo
+# atomic
+selector:
+  app: foo`,
+			update: `
+kind: Foo
+# atomic
+selector:
+  app: foo`,
+			local: `
+kind: Foo
+# atomic
+selector:
+  app: foo
+  tier: web`,
+			expected: `
+kind: Foo
+# atomic
+selector:
+  app: foo
+  tier: web`,
+		},
+		{
+			description: `map without a hint is merged`,
+			origin: `
+kind: Foo
+selector:
+  app: foo`,
+			update: `
+kind: Foo
+selector:
+  app: bar`,
+			local: `
+kind: Foo
+selector:
+  app: foo
+  tier: web`,
+			expected: `
+kind: Foo
+selector:
+  app: bar
+  tier: web`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{}.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
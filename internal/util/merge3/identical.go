@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// identicalInputs returns true if dest, original and update are written
+// identically, including their comments and formatting.
+func identicalInputs(dest, original, update *yaml.RNode) bool {
+	if dest == nil || original == nil || update == nil {
+		return false
+	}
+	d, err := dest.String()
+	if err != nil {
+		return false
+	}
+	for _, node := range []*yaml.RNode{original, update} {
+		if s, err := node.String(); err != nil || s != d {
+			return false
+		}
+	}
+	return true
+}
+
+// identicalResources returns true if the dest, original and update
+// resources are written identically, and in the same order.
+func identicalResources(dest, original, update []*yaml.RNode) bool {
+	if len(dest) != len(original) || len(dest) != len(update) {
+		return false
+	}
+	for i := range dest {
+		if !identicalInputs(dest[i], original[i], update[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_SkipIdenticalInputs(t *testing.T) {
+	// formatting which a merge normalizes
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: app    # the app
+    labels: {app: "app"}
+data:
+    a: >
+      folded
+    b: 'quoted'
+`
+
+	actual, report, err := Visitor{SkipIdenticalInputs: true, MatchDestStyle: true}.
+		MergeStrings(input, input, input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, input, actual)
+	assert.Empty(t, report.Conflicts)
+
+	// without the option the document is reformatted
+	actual, _, err = Visitor{}.MergeStrings(input, input, input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotEqual(t, input, actual)
+
+	// inputs which differ are merged
+	actual, _, err = Visitor{SkipIdenticalInputs: true}.MergeStrings(input, input, input+"    c: d\n")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, actual, "c: d")
+
+	// invalid inputs are still an error
+	_, _, err = Visitor{SkipIdenticalInputs: true}.MergeStrings("a: [", "a: [", "a: [")
+	assert.Error(t, err)
+}
+
+func TestVisitor_SkipIdenticalInputs_nodes(t *testing.T) {
+	input := "a: {b: 'c'} # comment\n"
+	dest := yaml.MustParse(input)
+	actual, report, err := Visitor{SkipIdenticalInputs: true}.
+		Merge(dest, yaml.MustParse(input), yaml.MustParse(input))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Same(t, dest, actual)
+	assert.Equal(t, &Report{}, report)
+
+	// nodes which differ are merged
+	actual, _, err = Visitor{SkipIdenticalInputs: true}.
+		Merge(yaml.MustParse(input), yaml.MustParse(input), yaml.MustParse("a: {b: 'd'} # comment\n"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "a: {b: 'd'} # comment\n", actual.MustString())
+}
+
+func TestRegistry_SkipIdenticalInputs(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: a    # the app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: b
+`
+
+	actual, reports, err := Registry{SkipIdenticalInputs: true}.MergeStrings(input, input, input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, input, actual)
+	assert.Empty(t, reports)
+
+	// without the option the streams are reformatted
+	actual, _, err = Registry{}.MergeStrings(input, input, input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotEqual(t, input, actual)
+
+	// identical resources are returned as they are
+	nodes, err := kio.FromBytes([]byte(input))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	result, reports, err := Registry{SkipIdenticalInputs: true}.Merge(nodes, nodes, nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, nodes, result)
+	assert.Empty(t, reports)
+
+	// invalid inputs are still an error
+	_, _, err = Registry{SkipIdenticalInputs: true}.MergeStrings("a: [", "a: [", "a: [")
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+// ListIdentity controls how the elements of a list are matched between the
+// sources.
+type ListIdentity int
+
+const (
+	// ListIdentityDefault matches elements by the merge keys of the schema,
+	// or the inferred merge key if InferAssociativeLists is set.  Lists
+	// without merge keys are merged as a whole.
+	ListIdentityDefault ListIdentity = iota
+
+	// ListIdentityMergeKey matches elements by their merge keys, inferring
+	// the merge key if the schema doesn't have one, even if
+	// InferAssociativeLists isn't set.
+	ListIdentityMergeKey
+
+	// ListIdentityContent matches elements by their entire content, like
+	// ContentIdentityLists, even if the list has merge keys.
+	ListIdentityContent
+)
+
+// listIdentity returns the ListIdentity configured for the list at path, and
+// the key it is configured by.  Lists are looked up by their path, and then
+// by their path pattern, e.g. `spec.containers[*].ports`.
+func (m Visitor) listIdentity(path []string) (ListIdentity, string) {
+	p := pathString(path)
+	if identity, found := m.ListIdentities[p]; found {
+		return identity, p
+	}
+	p = pathPattern(p)
+	return m.ListIdentities[p], p
+}
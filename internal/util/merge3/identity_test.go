@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ListIdentities(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+  rules:
+  - name: a
+    value: 1
+`
+	update := `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+  rules:
+  - name: a
+    value: 2
+`
+	local := `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+    local: x
+  rules:
+  - name: a
+    value: 1
+    local: x
+`
+
+	var testCases = []struct {
+		description string
+		identities  map[string]ListIdentity
+		expected    string
+	}{
+		{
+			description: `lists without merge keys are merged as a whole`,
+			expected: `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+  rules:
+  - name: a
+    value: 2
+`,
+		},
+		{
+			description: `lists use different identities`,
+			identities: map[string]ListIdentity{
+				"spec.items": ListIdentityMergeKey,
+				"spec.rules": ListIdentityContent,
+			},
+			// the changed items element is merged by its name, while the
+			// changed rules element is a different element than update's
+			expected: `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+    local: x
+  rules:
+  - name: a
+    value: 1
+    local: x
+  - name: a
+    value: 2
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{ListIdentities: tc.identities}.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_ListIdentities_associative(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+  - name: b
+    value: 1
+`
+	update := `
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+  - name: b
+    value: 1
+`
+	local := `
+kind: Foo
+spec:
+  items:
+  - name: b
+    value: 1
+  - name: a
+    value: 1
+    local: x
+`
+
+	// content identity overrides the inferred merge key
+	actual, _, err := Visitor{
+		InferAssociativeLists: true,
+		ListIdentities:        map[string]ListIdentity{"spec.items": ListIdentityContent},
+	}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+spec:
+  items:
+  - name: b
+    value: 1
+  - name: a
+    value: 1
+    local: x
+  - name: a
+    value: 2
+`), strings.TrimSpace(actual))
+}
+
+func TestVisitor_ListIdentities_scalars(t *testing.T) {
+	actual, _, err := Visitor{ListIdentities: map[string]ListIdentity{"spec.args": ListIdentityContent}}.
+		MergeStrings(`spec: {args: [a, b]}`, `spec: {args: [a, b]}`, `spec: {args: [b, a, c]}`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `spec: {args: [a, b, c]}`, strings.TrimSpace(actual))
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// ImmutableFieldPolicy controls how a change by update to a field which
+// can't be changed once the resource is created is merged.
+type ImmutableFieldPolicy int
+
+const (
+	// ImmutableFieldsError fails the merge.
+	ImmutableFieldsError ImmutableFieldPolicy = iota
+
+	// ImmutableFieldsKeepDest keeps the dest value of the field.
+	ImmutableFieldsKeepDest
+)
+
+// KubernetesImmutableFields returns the fields of the built-in Kubernetes
+// kinds which the API server rejects changes to, for ImmutableFields.
+func KubernetesImmutableFields() map[string][]string {
+	return map[string][]string{
+		"Deployment": {"spec.selector"},
+		"ReplicaSet": {"spec.selector"},
+		"DaemonSet":  {"spec.selector"},
+		"StatefulSet": {
+			"spec.selector",
+			"spec.serviceName",
+			"spec.podManagementPolicy",
+			"spec.volumeClaimTemplates",
+		},
+		"Job":     {"spec.selector", "spec.template"},
+		"Service": {"spec.clusterIP"},
+	}
+}
+
+// immutableField returns dest, and true, if the field at path is immutable
+// for the kind of the resource and merging update would change dest's value.
+// It returns an error instead if ImmutableFieldChanges is
+// ImmutableFieldsError.
+func (m Visitor) immutableField(nodes walk.Sources, path []string) (*yaml.RNode, bool, error) {
+	if !containsPath(m.ImmutableFields[m.kind], path) || yaml.IsMissingOrNull(nodes.Dest()) {
+		return nil, false, nil
+	}
+	var hashes []string
+	for _, node := range []*yaml.RNode{nodes.Dest(), nodes.Origin(), nodes.Updated()} {
+		h, err := Hash(node)
+		if err != nil {
+			return nil, false, err
+		}
+		hashes = append(hashes, h)
+	}
+	dest, origin, update := hashes[0], hashes[1], hashes[2]
+	if update == origin || update == dest {
+		// merging doesn't change dest
+		return nil, false, nil
+	}
+	m.matchRule("ImmutableFields: "+m.kind+" "+pathString(path), path)
+	if m.ImmutableFieldChanges == ImmutableFieldsError {
+		return nil, false, &Error{
+			Path:    pathString(path),
+			Kind:    ErrorKindImmutableField,
+			Message: fmt.Sprintf("%s of %s is immutable, but update changed it", pathString(path), m.kind),
+		}
+	}
+	return nodes.Dest(), true, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ImmutableFields(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		policy      ImmutableFieldPolicy
+		err         *Error
+	}{
+		{
+			description: `selector change by update is an error`,
+			origin: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  replicas: 1
+`,
+			update: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+  replicas: 2
+`,
+			local: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  replicas: 1
+`,
+			err: &Error{
+				Path:    "spec.selector",
+				Kind:    ErrorKindImmutableField,
+				Message: "spec.selector of Deployment is immutable, but update changed it",
+			},
+		},
+		{
+			description: `selector change by update keeps dest`,
+			origin: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  replicas: 1
+`,
+			update: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+  replicas: 2
+`,
+			local: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  replicas: 1
+`,
+			expected: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  replicas: 2
+`,
+			policy: ImmutableFieldsKeepDest,
+		},
+		{
+			description: `selector removed by update keeps dest`,
+			origin: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+`,
+			update: `
+kind: Deployment
+spec: {}
+`,
+			local: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+`,
+			expected: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+`,
+			policy: ImmutableFieldsKeepDest,
+		},
+		{
+			description: `selector added by update to a new dest is merged`,
+			origin: `
+kind: Deployment
+`,
+			update: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+			local: `
+kind: Deployment
+`,
+			expected: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+		},
+		{
+			description: `selector change matching dest is merged`,
+			origin: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+`,
+			update: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+			local: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+			expected: `
+kind: Deployment
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+		},
+		{
+			description: `selector of other kinds is merged`,
+			origin: `
+kind: NetworkPolicy
+spec:
+  selector:
+    app: nginx
+`,
+			update: `
+kind: NetworkPolicy
+spec:
+  selector:
+    app: web
+`,
+			local: `
+kind: NetworkPolicy
+spec:
+  selector:
+    app: nginx
+`,
+			expected: `
+kind: NetworkPolicy
+spec:
+  selector:
+    app: web
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{
+				ImmutableFields:       KubernetesImmutableFields(),
+				ImmutableFieldChanges: tc.policy,
+			}.MergeStrings(tc.local, tc.origin, tc.update)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_ImmutableFields_configured(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  volumeName: a
+`
+	update := `
+kind: Foo
+spec:
+  volumeName: b
+`
+
+	_, _, err := Visitor{
+		ImmutableFields: map[string][]string{"Foo": {"spec.volumeName"}},
+	}.MergeStrings(origin, origin, update)
+	assert.Equal(t, &Error{
+		Path:    "spec.volumeName",
+		Kind:    ErrorKindImmutableField,
+		Message: "spec.volumeName of Foo is immutable, but update changed it",
+	}, err)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// KeylessElementPolicy controls how the elements of an associative list
+// which don't have the merge key are merged.
+type KeylessElementPolicy int
+
+const (
+	// KeylessElementsMerge treats keyless elements as having empty merge
+	// key values, so that all of them are merged as the same element.
+	KeylessElementsMerge KeylessElementPolicy = iota
+
+	// KeylessElementsError fails the merge if any of the sources has a
+	// keyless element.
+	KeylessElementsError
+
+	// KeylessElementsByPosition merges the keyless elements of the sources
+	// by their position among the keyless elements, and appends them after
+	// the keyed elements.
+	KeylessElementsByPosition
+
+	// KeylessElementsByContent merges the keyless elements as an ordered set
+	// of elements identified by their content, like ContentIdentityLists,
+	// and appends them after the keyed elements.
+	KeylessElementsByContent
+)
+
+// keylessElements contains the keyless elements of each source, indexed like
+// walk.Sources.
+type keylessElements [3][]*yaml.Node
+
+// splitKeylessElements removes the elements without any of the merge keys
+// from the sources, and returns the remaining sources and the removed
+// elements.  The dest list is modified in place, since it is the merge
+// target.  Returns false if none of the sources has keyless elements.
+func splitKeylessElements(sources walk.Sources, keys []string) (walk.Sources, keylessElements, bool) {
+	var keyless keylessElements
+	var found bool
+	result := make(walk.Sources, len(sources))
+	copy(result, sources)
+	for i, s := range sources {
+		if i >= len(keyless) || yaml.IsMissingOrNull(s) {
+			continue
+		}
+		var keyed []*yaml.Node
+		for _, e := range s.Content() {
+			if isKeyless(e, keys) {
+				keyless[i] = append(keyless[i], e)
+				continue
+			}
+			keyed = append(keyed, e)
+		}
+		if len(keyless[i]) == 0 {
+			continue
+		}
+		found = true
+		if i == walk.DestIndex {
+			s.YNode().Content = keyed
+			continue
+		}
+		n := *s.YNode()
+		n.Content = keyed
+		result[i] = yaml.NewRNode(&n)
+	}
+	return result, keyless, found
+}
+
+// isKeyless returns true if the list element doesn't have a value for any
+// of the merge keys.
+func isKeyless(e *yaml.Node, keys []string) bool {
+	if e.Kind != yaml.MappingNode {
+		return true
+	}
+	return strings.Join(elementKeyValues(e, keys), "") == ""
+}
+
+// missingMergeKey returns the error for a list with keyless elements.
+func missingMergeKey(path []string, keys []string) error {
+	return &Error{
+		Path: pathString(path),
+		Kind: ErrorKindMissingMergeKey,
+		Message: fmt.Sprintf("list %s has elements without the merge key %s",
+			pathString(path), strings.Join(keys, ",")),
+	}
+}
+
+// mergeKeylessElements merges the keyless elements of the sources using the
+// KeylessElements policy, and returns the merged elements.
+func (l walker) mergeKeylessElements(keyless keylessElements) ([]*yaml.Node, error) {
+	switch l.visitor.KeylessElements {
+	case KeylessElementsByPosition:
+		var s *openapi.ResourceSchema
+		if l.schema != nil {
+			s = l.schema.Elements()
+		}
+		var n int
+		for i := range keyless {
+			if len(keyless[i]) > n {
+				n = len(keyless[i])
+			}
+		}
+		var result []*yaml.Node
+		for i := 0; i < n; i++ {
+			sources := make(walk.Sources, len(keyless))
+			for j := range keyless {
+				if i < len(keyless[j]) {
+					sources[j] = yaml.NewRNode(keyless[j][i])
+				}
+			}
+			node, err := l.child(sources, s, fmt.Sprintf("[%d]", i)).walk()
+			if err != nil {
+				return nil, err
+			}
+			if !yaml.IsMissingOrNull(node) {
+				result = append(result, node.YNode())
+			}
+		}
+		return result, nil
+	case KeylessElementsByContent:
+		lists := make(walk.Sources, len(keyless))
+		for i := range keyless {
+			lists[i] = yaml.NewRNode(&yaml.Node{Kind: yaml.SequenceNode, Content: keyless[i]})
+		}
+		node := orderedUnion(lists, contentID)
+		if yaml.IsMissingOrNull(node) {
+			return nil, nil
+		}
+		return node.YNode().Content, nil
+	default:
+		return nil, nil
+	}
+}
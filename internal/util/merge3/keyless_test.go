@@ -0,0 +1,143 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_KeylessElements(t *testing.T) {
+	origin := `
+# merge-key: name
+items:
+- name: a
+  value: origin
+- value: x
+- value: y
+`
+	update := `
+# merge-key: name
+items:
+- name: a
+  value: update
+- value: x
+- value: y2
+- value: z
+`
+	local := `
+# merge-key: name
+items:
+- value: y
+- name: a
+  value: origin
+- value: x
+  local: true
+`
+
+	var testCases = []struct {
+		description string
+		policy      KeylessElementPolicy
+		expected    string
+		err         string
+	}{
+		{
+			description: `keyless elements fail the merge`,
+			policy:      KeylessElementsError,
+			err:         `list items has elements without the merge key name`,
+		},
+		{
+			description: `keyless elements are merged by position`,
+			policy:      KeylessElementsByPosition,
+			expected: `
+# merge-key: name
+items:
+- name: a
+  value: update
+- value: y
+- value: y2
+  local: true
+- value: z
+`,
+		},
+		{
+			description: `keyless elements are merged by content`,
+			policy:      KeylessElementsByContent,
+			expected: `
+# merge-key: name
+items:
+- name: a
+  value: update
+- value: x
+  local: true
+- value: y2
+- value: z
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{KeylessElements: tc.policy}.MergeStrings(local, origin, update)
+			if tc.err != "" {
+				if !assert.EqualError(t, err, tc.err) {
+					t.FailNow()
+				}
+				assert.Equal(t, ErrorKindMissingMergeKey, err.(*Error).Kind)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_KeylessElements_scalars(t *testing.T) {
+	origin := `
+# merge-key: name
+items:
+- name: a
+- x
+- y
+`
+	update := `
+# merge-key: name
+items:
+- name: a
+- y
+- x
+- z
+`
+	expected := `
+# merge-key: name
+items:
+- name: a
+- x
+- y
+- z
+`
+
+	actual, _, err := Visitor{KeylessElements: KeylessElementsByContent}.MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+}
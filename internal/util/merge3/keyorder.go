@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// orderedFieldNames returns the names of all fields that appear in any of
+// the sources, with the dest fields in dest order followed by the fields
+// added in update in update order.  Since fields are merged in this order,
+// the fields update added are appended to dest in the order update has them.
+func (l walker) orderedFieldNames() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, i := range []int{walk.DestIndex, walk.UpdatedIndex, walk.OriginIndex} {
+		if i >= len(l.sources) || yaml.IsMissingOrNull(l.sources[i]) {
+			continue
+		}
+		// don't check error, we know this is a mapping node
+		fields, _ := l.sources[i].Fields()
+		for _, f := range fields {
+			if !seen[f] {
+				seen[f] = true
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_KeepAddedKeyOrder(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  b: 1
+  a: 1
+`
+	update := `
+kind: Foo
+spec:
+  zone: us
+  a: 2
+  region: us-east
+  b: 1
+  cluster: c
+`
+	local := `
+kind: Foo
+spec:
+  b: 1
+  a: 1
+  local: true
+`
+
+	var testCases = []struct {
+		description       string
+		keepAddedKeyOrder bool
+		expected          string
+	}{
+		{
+			description:       `added keys keep update's order`,
+			keepAddedKeyOrder: true,
+			expected: `
+kind: Foo
+spec:
+  b: 1
+  a: 2
+  local: true
+  zone: us
+  region: us-east
+  cluster: c
+`,
+		},
+		{
+			description: `added keys are sorted without the option`,
+			expected: `
+kind: Foo
+spec:
+  b: 1
+  a: 2
+  local: true
+  cluster: c
+  region: us-east
+  zone: us
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{KeepAddedKeyOrder: tc.keepAddedKeyOrder}.
+				MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strconv"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// normalizeMergeKeys returns the sources with the merge key values of the
+// origin and update elements written like the first source writing the
+// same number, e.g. dest's `8080` for update's `"8080"` or `0x1F90`, so
+// that the elements are matched.  Strings are only matched with numbers
+// which one of the sources writes as a number, so that distinct names
+// which happen to be digits, e.g. `"010"` and `"10"`, are kept apart.
+// Sources are copied before they are changed, and dest is never changed.
+func (l walker) normalizeMergeKeys(keys []string) walk.Sources {
+	if !l.visitor.NormalizeMergeKeys || len(keys) == 0 || keys[0] == "" {
+		return l.sources
+	}
+	sources := make(walk.Sources, len(l.sources))
+	copy(sources, l.sources)
+
+	// the numbers written as numbers, and the first way each number is
+	// written, by key
+	numbers := map[string]map[string]bool{}
+	written := map[string]map[string]string{}
+	for _, key := range keys {
+		numbers[key] = map[string]bool{}
+		written[key] = map[string]string{}
+	}
+	for _, s := range sources {
+		if yaml.IsMissingOrNull(s) {
+			continue
+		}
+		for _, element := range s.Content() {
+			for _, key := range keys {
+				field := yaml.NewRNode(element).Field(key)
+				if field == nil {
+					continue
+				}
+				if number, ok := canonicalNumber(field.Value); ok {
+					numbers[key][number] = true
+				}
+			}
+		}
+	}
+	for i, s := range sources {
+		if yaml.IsMissingOrNull(s) {
+			continue
+		}
+		copied := false
+		for j, element := range s.Content() {
+			for _, key := range keys {
+				field := yaml.NewRNode(element).Field(key)
+				if field == nil || !isScalar(field.Value) {
+					continue
+				}
+				value := field.Value.YNode().Value
+				number, ok := keyNumber(field.Value)
+				if !ok || !numbers[key][number] {
+					continue
+				}
+				first, found := written[key][number]
+				if !found {
+					written[key][number] = value
+					continue
+				}
+				if first == value || i == walk.DestIndex {
+					continue
+				}
+				if !copied {
+					s = s.Copy()
+					sources[i], copied = s, true
+				}
+				// rewrite the value on the copy like the first source
+				yaml.NewRNode(s.Content()[j]).Field(key).Value.YNode().Value = first
+			}
+		}
+	}
+	return sources
+}
+
+// keyNumber returns the canonical form of a merge key value which is a
+// number, or a string containing a decimal integer.
+func keyNumber(node *yaml.RNode) (string, bool) {
+	if n, ok := canonicalNumber(node); ok {
+		return n, true
+	}
+	if node.YNode().ShortTag() != yaml.NodeTagString {
+		return "", false
+	}
+	if i, err := strconv.ParseInt(node.YNode().Value, 10, 64); err == nil {
+		return strconv.FormatInt(i, 10), true
+	}
+	return "", false
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_NormalizeMergeKeys(t *testing.T) {
+	origin := `
+kind: Foo
+# merge-key: containerPort
+ports:
+- containerPort: 8080
+  protocol: TCP
+- containerPort: 9090
+  protocol: TCP
+`
+	update := `
+kind: Foo
+# merge-key: containerPort
+ports:
+- containerPort: "8080"
+  protocol: UDP
+- containerPort: 0x2382
+  protocol: UDP
+`
+	local := `
+kind: Foo
+# merge-key: containerPort
+ports:
+- containerPort: 0x1F90
+  protocol: TCP
+  name: http
+- containerPort: "9090"
+  protocol: TCP
+  name: metrics
+`
+
+	actual, _, err := Visitor{NormalizeMergeKeys: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+# merge-key: containerPort
+ports:
+- containerPort: 0x1F90
+  protocol: UDP
+  name: http
+- containerPort: "9090"
+  protocol: UDP
+  name: metrics
+`), strings.TrimSpace(actual))
+
+	// without the option the elements written differently aren't matched
+	actual, _, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 4, strings.Count(actual, "containerPort:"))
+
+	// the merge doesn't change the update elements
+	u := yaml.MustParse(update)
+	_, _, err = Visitor{NormalizeMergeKeys: true}.
+		Merge(yaml.MustParse(local), yaml.MustParse(origin), u)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(update), strings.TrimSpace(u.MustString()))
+}
+
+func TestVisitor_NormalizeMergeKeys_strings(t *testing.T) {
+	origin := `
+kind: Foo
+# merge-key: name
+items:
+- name: "8"
+  value: a
+- name: "10"
+  value: b
+`
+	update := `
+kind: Foo
+# merge-key: name
+items:
+- name: "8"
+  value: c
+- name: "10"
+  value: d
+`
+	local := `
+kind: Foo
+# merge-key: name
+items:
+- name: "010"
+  value: e
+`
+
+	// names which aren't written as numbers by any source are distinct,
+	// and strings are parsed as decimal
+	actual, _, err := Visitor{NormalizeMergeKeys: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, actual, "- name: \"010\"\n  value: e\n")
+	assert.Equal(t, 3, strings.Count(actual, "name:"))
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// LastAppliedAnnotation is the annotation kubectl apply records the applied
+// configuration of a resource in.
+const LastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// LastAppliedPolicy controls how the LastAppliedAnnotation of resources is
+// merged.
+type LastAppliedPolicy int
+
+const (
+	// LastAppliedMerge merges the annotation like any other field.
+	LastAppliedMerge LastAppliedPolicy = iota
+
+	// LastAppliedUpdate sets the annotation to the JSON of the merged
+	// resource without the annotation, as kubectl apply would, if the
+	// resource had the annotation.
+	LastAppliedUpdate
+
+	// LastAppliedStrip removes the annotation from the merged resource.
+	LastAppliedStrip
+)
+
+// updateLastApplied updates or removes the LastAppliedAnnotation of the
+// merged result according to the LastApplied policy.
+func (m Visitor) updateLastApplied(result *yaml.RNode) error {
+	if m.LastApplied == LastAppliedMerge || yaml.IsMissingOrNull(result) ||
+		result.YNode().Kind != yaml.MappingNode {
+		return nil
+	}
+	annotations, err := result.Pipe(yaml.Lookup(yaml.MetadataField, yaml.AnnotationsField))
+	if err != nil || annotations == nil || annotations.Field(LastAppliedAnnotation) == nil {
+		return err
+	}
+	if _, err := annotations.Pipe(yaml.Clear(LastAppliedAnnotation)); err != nil {
+		return err
+	}
+	if m.LastApplied == LastAppliedStrip {
+		if len(annotations.Content()) == 0 {
+			_, err = result.Pipe(yaml.Lookup(yaml.MetadataField), yaml.Clear(yaml.AnnotationsField))
+		}
+		return err
+	}
+
+	// the configuration doesn't include an annotations map which only had
+	// the annotation
+	config := result.Copy()
+	if len(annotations.Content()) == 0 {
+		if _, err := config.Pipe(yaml.Lookup(yaml.MetadataField), yaml.Clear(yaml.AnnotationsField)); err != nil {
+			return err
+		}
+	}
+	b, err := config.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return annotations.PipeE(yaml.SetField(LastAppliedAnnotation, yaml.NewStringRNode(string(b)+"\n")))
+}
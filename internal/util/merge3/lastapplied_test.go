@@ -0,0 +1,160 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_LastApplied(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  a: "1"
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  a: "2"
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","data":{"a":"1"},"kind":"ConfigMap","metadata":{"name":"app"}}
+data:
+  a: "1"
+  b: "3"
+`
+
+	var testCases = []struct {
+		description string
+		policy      LastAppliedPolicy
+		local       string
+		expected    string
+	}{
+		{
+			description: `update the annotation`,
+			policy:      LastAppliedUpdate,
+			local:       local,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","data":{"a":"2","b":"3"},"kind":"ConfigMap","metadata":{"name":"app"}}
+data:
+  a: "2"
+  b: "3"`,
+		},
+		{
+			description: `strip the annotation`,
+			policy:      LastAppliedStrip,
+			local:       local,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  a: "2"
+  b: "3"`,
+		},
+		{
+			description: `merge the annotation`,
+			policy:      LastAppliedMerge,
+			local:       local,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","data":{"a":"1"},"kind":"ConfigMap","metadata":{"name":"app"}}
+data:
+  a: "2"
+  b: "3"`,
+		},
+		{
+			description: `the annotation isn't added`,
+			policy:      LastAppliedUpdate,
+			local:       origin,
+			expected:    update,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{LastApplied: tc.policy}.MergeStrings(tc.local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_LastApplied_otherAnnotations(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  annotations:
+    team: a
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+`
+
+	actual, _, err := Visitor{LastApplied: LastAppliedUpdate}.MergeStrings(local, origin, origin)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	node, err := yaml.Parse(actual)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	annotations, err := node.GetAnnotations()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the configuration has the other annotations, but not itself
+	assert.Equal(t,
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"annotations":{"team":"a"},"name":"app"}}`+"\n",
+		annotations[LastAppliedAnnotation])
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// lineage maps the path of each scalar in the sources to its values.  List
+// elements are identified by `[]` rather than by their merge keys, e.g.
+// `spec.containers[].image`, so that elements are matched regardless of
+// their position.
+type lineage map[string]sets.String
+
+// sourceLineage returns the lineage of the sources.  It must be called
+// before the merge, which modifies dest.
+func sourceLineage(sources ...*yaml.RNode) lineage {
+	l := lineage{}
+	for _, s := range sources {
+		if s != nil {
+			l.add(s.YNode(), "")
+		}
+	}
+	return l
+}
+
+// add adds the scalars of node at path to the lineage.
+func (l lineage) add(node *yaml.Node, path string) {
+	l.walk(node, path, func(path, value string) bool {
+		if l[path] == nil {
+			l[path] = sets.String{}
+		}
+		l[path].Insert(value)
+		return true
+	})
+}
+
+// walk calls fn with the path and value of each scalar of node until fn
+// returns false.
+func (l lineage) walk(node *yaml.Node, path string, fn func(path, value string) bool) bool {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			if !l.walk(n, path, fn) {
+				return false
+			}
+		}
+	case yaml.AliasNode:
+		return l.walk(node.Alias, path, fn)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			p := node.Content[i].Value
+			if path != "" {
+				p = path + "." + p
+			}
+			if !l.walk(node.Content[i+1], p, fn) {
+				return false
+			}
+		}
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			if !l.walk(n, path+"[]", fn) {
+				return false
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(path, node.Value)
+	}
+	return true
+}
+
+// validate returns an error for the first scalar of the merged result which
+// doesn't have the value of a scalar at the same path in one of the
+// sources, e.g. because a hook injected it.
+func (l lineage) validate(result *yaml.RNode) error {
+	if yaml.IsMissingOrNull(result) {
+		return nil
+	}
+	var err error
+	l.walk(result.YNode(), "", func(path, value string) bool {
+		if l[path].Has(value) {
+			return true
+		}
+		err = &Error{
+			Path:    path,
+			Kind:    ErrorKindLineage,
+			Message: fmt.Sprintf("%s has the value %q which isn't in origin, dest or update", path, value),
+		}
+		return false
+	})
+	return err
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_ValidateLineage(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a]
+`
+	update := `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, b]
+      - name: sidecar
+        image: sidecar:1.0
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: local
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a, c]
+`
+
+	// a well-behaved merge passes
+	actual, _, err := Visitor{ValidateLineage: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: local
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, b]
+      - image: sidecar:1.0
+        name: sidecar
+`), strings.TrimSpace(actual))
+
+	// a hook injecting a value is flagged
+	inject := func(value *yaml.RNode) (*yaml.RNode, error) {
+		return yaml.NewScalarRNode("registry.example.com/" + value.YNode().Value), nil
+	}
+	_, _, err = Visitor{
+		ValidateLineage: true,
+		Transforms:      map[string]Transform{"spec.template.spec.containers[*].image": inject},
+	}.MergeStrings(local, origin, update)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, &Error{
+		Path: "spec.template.spec.containers[].image",
+		Kind: ErrorKindLineage,
+		Message: `spec.template.spec.containers[].image has the value ` +
+			`"registry.example.com/nginx:1.8" which isn't in origin, dest or update`,
+	}, err)
+
+	// the hook isn't flagged without the option
+	_, _, err = Visitor{
+		Transforms: map[string]Transform{"spec.template.spec.containers[*].image": inject},
+	}.MergeStrings(local, origin, update)
+	assert.NoError(t, err)
+}
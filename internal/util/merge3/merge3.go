@@ -0,0 +1,24 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package merge3 contains libraries for performing a three-way merge of
+// RNodes.  It is a fork of the kyaml merge3 package, which allows kpt to
+// extend the merge behavior and report on the merged fields.
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Merge merges the changes between original and update into dest.
+func Merge(dest, original, update *yaml.RNode) (*yaml.RNode, error) {
+	// if update == nil && original != nil => declarative deletion
+	result, _, err := Visitor{}.Merge(dest, original, update)
+	return result, err
+}
+
+// MergeStrings parses dest, original and update and merges them.
+func MergeStrings(dest, original, update string, infer bool) (string, error) {
+	result, _, err := Visitor{InferAssociativeLists: infer}.MergeStrings(dest, original, update)
+	return result, err
+}
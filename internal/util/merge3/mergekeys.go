@@ -0,0 +1,57 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+// MergeKeySource identifies how the merge keys of an associative list were
+// chosen.
+type MergeKeySource string
+
+const (
+	// MergeKeySchema is a merge key configured in the OpenAPI schema, or in
+	// a field's schema comment.
+	MergeKeySchema MergeKeySource = "schema"
+
+	// MergeKeyHint is a merge key declared by an inline `# merge-key` hint.
+	MergeKeyHint MergeKeySource = "hint"
+
+	// MergeKeyInferred is a merge key inferred from the fields of the list
+	// elements, when InferAssociativeLists is set.
+	MergeKeyInferred MergeKeySource = "inferred"
+)
+
+// MergeKeys records the merge keys used for an associative list.
+type MergeKeys struct {
+	// Path is the path to the list.
+	Path string `json:"path"`
+
+	// Keys are the merge keys.  Lists of scalars which are identified by
+	// their values have no keys.
+	Keys []string `json:"keys,omitempty"`
+
+	// Source identifies how the keys were chosen.
+	Source MergeKeySource `json:"source"`
+}
+
+// recordMergeKeys records the merge keys for the associative list at path.
+func (m Visitor) recordMergeKeys(path []string, keys []string, source MergeKeySource) {
+	if !m.ReportMergeKeys || m.report == nil {
+		return
+	}
+	m.report.MergeKeys = append(m.report.MergeKeys, MergeKeys{
+		Path:   pathString(path),
+		Keys:   append([]string(nil), keys...),
+		Source: source,
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ReportMergeKeys(t *testing.T) {
+	resource := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  finalizers: [a]
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+  # merge-key: id
+  routes:
+  - id: a
+  extensions:
+  - name: a
+`
+	v := Visitor{InferAssociativeLists: true, ReportMergeKeys: true}
+	_, report, err := v.MergeStrings(resource, resource, resource)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []MergeKeys{
+		{Path: "metadata.finalizers", Source: MergeKeySchema},
+		{Path: "spec.extensions", Keys: []string{"name"}, Source: MergeKeyInferred},
+		{Path: "spec.routes", Keys: []string{"id"}, Source: MergeKeyHint},
+		{Path: "spec.template.spec.containers", Keys: []string{"name"}, Source: MergeKeySchema},
+	}, report.MergeKeys)
+
+	// merge keys are only reported when requested
+	_, report, err = Visitor{InferAssociativeLists: true}.MergeStrings(resource, resource, resource)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.MergeKeys)
+}
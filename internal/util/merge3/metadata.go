@@ -0,0 +1,73 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// MetadataMapPolicy controls how the `metadata.labels` or
+// `metadata.annotations` of resources are merged.
+type MetadataMapPolicy int
+
+const (
+	// MetadataMapMerge merges the map like any other field.
+	MetadataMapMerge MetadataMapPolicy = iota
+
+	// MetadataMapAdditive merges the map without removing any entries, so
+	// that dest keeps the entries update removed along with its own.
+	MetadataMapAdditive
+
+	// MetadataMapAuthoritative takes the map from update as a whole,
+	// removing the entries update doesn't have.
+	MetadataMapAuthoritative
+)
+
+const (
+	ruleLabels      = "Labels"
+	ruleAnnotations = "Annotations"
+)
+
+// metadataMap returns the merged value of the labels or annotations, or one
+// of their entries, and true if path is one of them and it is not merged
+// like other fields.
+func (m Visitor) metadataMap(nodes walk.Sources, path []string) (*yaml.RNode, string, bool) {
+	if len(path) < 2 || len(path) > 3 || path[0] != "metadata" {
+		return nil, "", false
+	}
+	var policy MetadataMapPolicy
+	var rule string
+	switch path[1] {
+	case "labels":
+		policy, rule = m.Labels, ruleLabels
+	case "annotations":
+		policy, rule = m.Annotations, ruleAnnotations
+	default:
+		return nil, "", false
+	}
+
+	switch {
+	case policy == MetadataMapAuthoritative && len(path) == 2:
+		m.matchRule(rule, path)
+		return nodes.Updated(), "took update because it is authoritative", true
+	case policy == MetadataMapAdditive && yaml.IsMissingOrNull(nodes.Updated()) &&
+		!yaml.IsMissingOrNull(nodes.Dest()):
+		m.matchRule(rule, path)
+		return nodes.Dest(), "kept dest because it is additive", true
+	default:
+		return nil, "", false
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_MetadataMaps(t *testing.T) {
+	origin := `
+metadata:
+  name: app
+  labels:
+    app: app
+    tier: web
+  annotations:
+    owner: platform
+    note: origin
+`
+	update := `
+metadata:
+  name: app
+  labels:
+    app: app
+    version: v2
+  annotations:
+    note: update
+    docs: example.com
+`
+	local := `
+metadata:
+  name: app
+  labels:
+    app: app
+    tier: web
+    team: local
+  annotations:
+    owner: platform
+    note: origin
+    local: "true"
+`
+
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		expected    string
+	}{
+		{
+			description: `annotations accumulate while labels follow update`,
+			visitor:     Visitor{Labels: MetadataMapAuthoritative, Annotations: MetadataMapAdditive},
+			expected: `
+metadata:
+  name: app
+  labels:
+    app: app
+    version: v2
+  annotations:
+    owner: platform
+    note: update
+    local: "true"
+    docs: example.com
+`,
+		},
+		{
+			description: `labels and annotations are merged by default`,
+			expected: `
+metadata:
+  name: app
+  labels:
+    app: app
+    team: local
+    version: v2
+  annotations:
+    note: update
+    local: "true"
+    docs: example.com
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
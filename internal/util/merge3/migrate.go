@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// migrateSequences converts the sequences in sources to maps if update
+// converted the field to a map and SequencesToMaps configures the key, so
+// that the dest customizations of the elements are merged into the map
+// entries with the same key.
+func (l walker) migrateSequences() (walk.Sources, error) {
+	update := l.sources.Updated()
+	if len(l.visitor.SequencesToMaps) == 0 || yaml.IsMissingOrNull(update) ||
+		update.YNode().Kind != yaml.MappingNode {
+		return l.sources, nil
+	}
+	p := pathString(l.path)
+	key, found := l.visitor.SequencesToMaps[p]
+	if !found {
+		p = pathPattern(p)
+		if key, found = l.visitor.SequencesToMaps[p]; !found {
+			return l.sources, nil
+		}
+	}
+
+	sources := make(walk.Sources, len(l.sources))
+	copy(sources, l.sources)
+	for i, s := range sources {
+		if yaml.IsMissingOrNull(s) || s.YNode().Kind != yaml.SequenceNode {
+			continue
+		}
+		l.visitor.matchRule("SequencesToMaps: "+p, l.path)
+		m, err := sequenceToMap(s, key, l.path)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = m
+	}
+	return sources, nil
+}
+
+// sequenceToMap returns a map with an entry for each element of the sequence
+// node, keyed by the value of its key field.  The entries are copies of the
+// elements without the key field.  It is an error if an element at path
+// doesn't have the key field.
+func sequenceToMap(node *yaml.RNode, key string, path []string) (*yaml.RNode, error) {
+	m := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+	for _, e := range node.Content() {
+		element := yaml.NewRNode(e).Copy()
+		field := element.Field(key)
+		if e.Kind != yaml.MappingNode || field == nil || field.Value.YNode().Kind != yaml.ScalarNode {
+			return nil, missingMergeKey(path, []string{key})
+		}
+		name := field.Value.YNode().Value
+		if _, err := element.Pipe(yaml.Clear(key)); err != nil {
+			return nil, err
+		}
+		if err := m.PipeE(yaml.SetField(name, element)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
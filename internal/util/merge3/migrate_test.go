@@ -0,0 +1,174 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_SequencesToMaps(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		migrations  map[string]string
+		err         string
+	}{
+		{
+			description: `dest customizations are carried to the map entries`,
+			origin: `
+spec:
+  ports:
+  - name: http
+    port: 80
+  - name: https
+    port: 443`,
+			update: `
+spec:
+  ports:
+    http:
+      port: 8080
+    https:
+      port: 443`,
+			local: `
+spec:
+  ports:
+  - name: http
+    port: 80
+    nodePort: 30080
+  - name: https
+    port: 443
+  - name: metrics
+    port: 9090`,
+			expected: `
+spec:
+  ports:
+    http:
+      port: 8080
+      nodePort: 30080
+    https:
+      port: 443
+    metrics:
+      port: 9090`,
+			migrations: map[string]string{"spec.ports": "name"},
+		},
+		{
+			description: `dest which was already migrated`,
+			origin: `
+spec:
+  ports:
+  - name: http
+    port: 80`,
+			update: `
+spec:
+  ports:
+    http:
+      port: 8080`,
+			local: `
+spec:
+  ports:
+    http:
+      port: 80
+      nodePort: 30080`,
+			expected: `
+spec:
+  ports:
+    http:
+      port: 8080
+      nodePort: 30080`,
+			migrations: map[string]string{"spec.ports": "name"},
+		},
+		{
+			description: `path pattern`,
+			origin: `
+spec:
+  containers:
+  - name: app
+    env:
+    - name: A
+      value: "1"`,
+			update: `
+spec:
+  containers:
+  - name: app
+    env:
+      A:
+        value: "2"`,
+			local: `
+spec:
+  containers:
+  - name: app
+    env:
+    - name: A
+      value: "1"
+    - name: B
+      value: "3"`,
+			expected: `
+spec:
+  containers:
+  - name: app
+    env:
+      A:
+        value: "2"
+      B:
+        value: "3"`,
+			migrations: map[string]string{"spec.containers[*].env": "name"},
+		},
+		{
+			description: `elements without the key are an error`,
+			origin: `
+spec:
+  ports:
+  - name: http
+    port: 80`,
+			update: `
+spec:
+  ports:
+    http:
+      port: 8080`,
+			local: `
+spec:
+  ports:
+  - port: 80`,
+			migrations: map[string]string{"spec.ports": "name"},
+			err:        "list spec.ports has elements without the merge key name",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InferAssociativeLists: true, SequencesToMaps: tc.migrations}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if tc.err != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, tc.err, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import "strings"
+
+// TrailingNewlinePolicy controls the newlines at the end of the merged
+// output of MergeStrings.
+type TrailingNewlinePolicy int
+
+const (
+	// TrailingNewlineDefault ends the output with the single newline written
+	// by the encoder.
+	TrailingNewlineDefault TrailingNewlinePolicy = iota
+
+	// TrailingNewlinePreserve ends the output with the same newlines as
+	// dest, so that editors adding or removing them don't cause file-level
+	// diffs.
+	TrailingNewlinePreserve
+
+	// TrailingNewlineForce ends the output with exactly one newline.
+	TrailingNewlineForce
+
+	// TrailingNewlineStrip ends the output without a newline.
+	TrailingNewlineStrip
+)
+
+// apply returns s ending with the newlines required by the policy.
+func (p TrailingNewlinePolicy) apply(s, dest string) string {
+	switch p {
+	case TrailingNewlinePreserve:
+		trimmed := strings.TrimRight(dest, "\n")
+		return strings.TrimRight(s, "\n") + dest[len(trimmed):]
+	case TrailingNewlineForce:
+		return strings.TrimRight(s, "\n") + "\n"
+	case TrailingNewlineStrip:
+		return strings.TrimRight(s, "\n")
+	default:
+		return s
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_TrailingNewline(t *testing.T) {
+	var testCases = []struct {
+		description string
+		local       string
+		policy      TrailingNewlinePolicy
+		expected    string
+	}{
+		{
+			description: `default ends with a newline`,
+			local:       "kind: Foo\na: b",
+			expected:    "kind: Foo\na: c\n",
+		},
+		{
+			description: `preserve keeps a missing newline`,
+			local:       "kind: Foo\na: b",
+			policy:      TrailingNewlinePreserve,
+			expected:    "kind: Foo\na: c",
+		},
+		{
+			description: `preserve keeps a single newline`,
+			local:       "kind: Foo\na: b\n",
+			policy:      TrailingNewlinePreserve,
+			expected:    "kind: Foo\na: c\n",
+		},
+		{
+			description: `preserve keeps multiple newlines`,
+			local:       "kind: Foo\na: b\n\n",
+			policy:      TrailingNewlinePreserve,
+			expected:    "kind: Foo\na: c\n\n",
+		},
+		{
+			description: `force adds a missing newline`,
+			local:       "kind: Foo\na: b",
+			policy:      TrailingNewlineForce,
+			expected:    "kind: Foo\na: c\n",
+		},
+		{
+			description: `force collapses multiple newlines`,
+			local:       "kind: Foo\na: b\n\n",
+			policy:      TrailingNewlineForce,
+			expected:    "kind: Foo\na: c\n",
+		},
+		{
+			description: `strip removes the newline`,
+			local:       "kind: Foo\na: b\n",
+			policy:      TrailingNewlineStrip,
+			expected:    "kind: Foo\na: c",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{TrailingNewline: tc.policy}.
+				MergeStrings(tc.local, "kind: Foo\na: b\n", "kind: Foo\na: c\n")
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
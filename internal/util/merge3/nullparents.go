@@ -0,0 +1,34 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// initializesNullParent returns true if the map nodes is null in dest, e.g.
+// `spec:`, and should be initialized so that the fields update adds to it
+// can be merged.  A map which origin had is considered cleared by dest.
+func (m Visitor) initializesNullParent(nodes walk.Sources) bool {
+	if !m.InitializeNullParents || !nodes.Dest().IsTaggedNull() {
+		return false
+	}
+	origin, update := nodes.Origin(), nodes.Updated()
+	if !yaml.IsMissingOrNull(origin) && len(origin.Content()) > 0 {
+		return false
+	}
+	return !yaml.IsMissingOrNull(update) && update.YNode().Kind == yaml.MappingNode
+}
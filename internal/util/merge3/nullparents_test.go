@@ -0,0 +1,154 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_InitializeNullParents(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		initialize  bool
+	}{
+		{
+			description: `implicit null parent receives added children`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec:
+  a:
+    b: 1`,
+			local: `
+kind: Foo
+spec:`,
+			expected: `
+kind: Foo
+spec:
+  a:
+    b: 1`,
+			initialize: true,
+		},
+		{
+			description: `explicit null parent receives added children`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec:
+  a: 1`,
+			local: `
+kind: Foo
+spec: ~`,
+			expected: `
+kind: Foo
+spec:
+  a: 1`,
+			initialize: true,
+		},
+		{
+			description: `null parent which is null in origin receives added children`,
+			origin: `
+kind: Foo
+spec: null`,
+			update: `
+kind: Foo
+spec:
+  a: 1`,
+			local: `
+kind: Foo
+spec: !!null`,
+			expected: `
+kind: Foo
+spec:
+  a: 1`,
+			initialize: true,
+		},
+		{
+			description: `nested null parent receives added children`,
+			origin: `
+kind: Foo
+spec: {}`,
+			update: `
+kind: Foo
+spec:
+  template:
+    replicas: 1`,
+			local: `
+kind: Foo
+spec:
+  template:`,
+			expected: `
+kind: Foo
+spec:
+  template:
+    replicas: 1`,
+			initialize: true,
+		},
+		{
+			description: `parent cleared by dest stays deleted`,
+			origin: `
+kind: Foo
+spec:
+  a: 1`,
+			update: `
+kind: Foo
+spec:
+  a: 1
+  b: 2`,
+			local: `
+kind: Foo
+spec: null`,
+			expected: `
+kind: Foo`,
+			initialize: true,
+		},
+		{
+			description: `null parent is deleted without the option`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec:
+  a: 1`,
+			local: `
+kind: Foo
+spec:`,
+			expected: `
+kind: Foo`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InitializeNullParents: tc.initialize}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// NullElementPolicy controls how null elements in lists (e.g. `[a, null]`)
+// are merged.
+type NullElementPolicy int
+
+const (
+	// NullElementsAsValues merges null elements like any other value in
+	// lists which are merged as a whole.  Associative lists ignore null
+	// elements, and drop them from the result.
+	NullElementsAsValues NullElementPolicy = iota
+
+	// NullElementsDrop drops null elements from all lists.
+	NullElementsDrop
+
+	// NullElementsByPosition matches null elements by their index.  The
+	// null elements in dest are kept at their positions, except those which
+	// update removed from origin, and the null elements update added are
+	// inserted at their positions in update.
+	NullElementsByPosition
+)
+
+// withoutNullElements returns copies of the sources without their null
+// elements, and the indexes of the null elements in each source.  The
+// sources are returned as they are if they don't have null elements.
+func withoutNullElements(sources walk.Sources) (walk.Sources, [3][]int) {
+	var nulls [3][]int
+	result := make(walk.Sources, len(sources))
+	copy(result, sources)
+	for i, s := range sources {
+		if i >= len(nulls) || yaml.IsMissingOrNull(s) {
+			continue
+		}
+		var content []*yaml.Node
+		for j, e := range s.Content() {
+			if isNullElement(e) {
+				nulls[i] = append(nulls[i], j)
+				continue
+			}
+			content = append(content, e)
+		}
+		if len(nulls[i]) == 0 {
+			continue
+		}
+		n := *s.YNode()
+		n.Content = content
+		result[i] = yaml.NewRNode(&n)
+	}
+	return result, nulls
+}
+
+// insertNullElements inserts null elements into the merged list at the
+// positions they are found in dest, except those which update removed from
+// origin, and at the positions update added them.  The null elements are
+// written as `null`, unless preserve is set, in which case they keep the
+// representation they have in sources, e.g. `~` or `!!null`.
+func insertNullElements(list *yaml.RNode, nulls [3][]int, sources walk.Sources, preserve bool) {
+	if yaml.IsMissingOrNull(list) {
+		return
+	}
+	origin := map[int]bool{}
+	update := map[int]bool{}
+	for _, i := range nulls[walk.OriginIndex] {
+		origin[i] = true
+	}
+	for _, i := range nulls[walk.UpdatedIndex] {
+		update[i] = true
+	}
+
+	positions := map[int]int{}
+	for _, i := range nulls[walk.DestIndex] {
+		if origin[i] && !update[i] {
+			// removed by update
+			continue
+		}
+		positions[i] = walk.DestIndex
+	}
+	for _, i := range nulls[walk.UpdatedIndex] {
+		if !origin[i] {
+			positions[i] = walk.UpdatedIndex
+		}
+	}
+
+	var indexes []int
+	for i := range positions {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	content := list.YNode().Content
+	for _, i := range indexes {
+		null := &yaml.Node{Kind: yaml.ScalarNode, Tag: yaml.NodeTagNull, Value: "null"}
+		if preserve {
+			null = yaml.CopyYNode(sources[positions[i]].Content()[i])
+		}
+		if i > len(content) {
+			i = len(content)
+		}
+		content = append(content[:i], append([]*yaml.Node{null}, content[i:]...)...)
+	}
+	list.YNode().Content = content
+}
+
+// isNullElement returns true if the list element is null.
+func isNullElement(e *yaml.Node) bool {
+	return e.Kind == yaml.ScalarNode && e.ShortTag() == yaml.NodeTagNull
+}
@@ -0,0 +1,284 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_NullElements(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		policy      NullElementPolicy
+	}{
+		{
+			description: `null elements are values in non-associative lists`,
+			origin: `
+args: [a, null]`,
+			update: `
+args: [a, null, c]`,
+			local: `
+args: [a, null]`,
+			expected: `
+args: [a, null, c]`,
+		},
+		{
+			description: `null element in origin of an associative list`,
+			origin: `
+items:
+- name: a
+- null`,
+			update: `
+items:
+- name: a
+- name: b`,
+			local: `
+items:
+- name: a`,
+			expected: `
+items:
+- name: a
+- name: b`,
+		},
+		{
+			description: `null element in dest of an associative list is dropped`,
+			origin: `
+items:
+- name: a`,
+			update: `
+items:
+- name: a
+- name: b`,
+			local: `
+items:
+- name: a
+- null`,
+			expected: `
+items:
+- name: a
+- name: b`,
+		},
+		{
+			description: `drop null elements from non-associative lists`,
+			origin: `
+args: [a]`,
+			update: `
+args: [a, null, c]`,
+			local: `
+args: [a]`,
+			expected: `
+args: [a, c]`,
+			policy: NullElementsDrop,
+		},
+		{
+			description: `keep null elements of dest by position`,
+			origin: `
+items:
+- name: a`,
+			update: `
+items:
+- name: a
+- name: b`,
+			local: `
+items:
+- null
+- name: a`,
+			expected: `
+items:
+- null
+- name: a
+- name: b`,
+			policy: NullElementsByPosition,
+		},
+		{
+			description: `add null elements of update by position`,
+			origin: `
+items:
+- name: a
+- name: b`,
+			update: `
+items:
+- name: a
+- null
+- name: b`,
+			local: `
+items:
+- name: a
+- name: b
+- name: c`,
+			expected: `
+items:
+- name: a
+- null
+- name: b
+- name: c`,
+			policy: NullElementsByPosition,
+		},
+		{
+			description: `remove null elements removed by update`,
+			origin: `
+items:
+- name: a
+- null`,
+			update: `
+items:
+- name: a`,
+			local: `
+items:
+- name: a
+- null`,
+			expected: `
+items:
+- name: a`,
+			policy: NullElementsByPosition,
+		},
+		{
+			description: `null elements past the end are appended`,
+			origin: `
+args: [a, b, c]`,
+			update: `
+args: [a]`,
+			local: `
+args: [a, b, c, null]`,
+			expected: `
+args: [a, null]`,
+			policy: NullElementsByPosition,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InferAssociativeLists: true, NullElements: tc.policy}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_PreserveNullTags(t *testing.T) {
+	testCases := []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		preserve    bool
+	}{
+		{
+			description: `keep explicitly tagged null elements of dest`,
+			origin: `
+items:
+- name: a
+- name: b`,
+			update: `
+items:
+- name: a
+- name: b
+- name: c`,
+			local: `
+items:
+- !!null
+- name: a
+- ~
+- name: b
+-`,
+			expected: `
+items:
+- !!null
+- name: a
+- ~
+- name: b
+-
+- name: c`,
+			preserve: true,
+		},
+		{
+			description: `keep the tag of null elements added by update`,
+			origin: `
+items:
+- name: a
+- name: b`,
+			update: `
+items:
+- name: a
+- !!null
+- name: b`,
+			local: `
+items:
+- name: a
+- name: b
+- name: c`,
+			expected: `
+items:
+- name: a
+- !!null
+- name: b
+- name: c`,
+			preserve: true,
+		},
+		{
+			description: `write retained null elements as null by default`,
+			origin: `
+items:
+- name: a
+- name: b`,
+			update: `
+items:
+- name: a
+- name: b
+- name: c`,
+			local: `
+items:
+- !!null
+- name: a
+- ~
+- name: b`,
+			expected: `
+items:
+- null
+- name: a
+- null
+- name: b
+- name: c`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{
+				InferAssociativeLists: true,
+				NullElements:          NullElementsByPosition,
+				PreserveNullTags:      tc.preserve,
+			}.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
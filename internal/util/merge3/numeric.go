@@ -0,0 +1,106 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"math"
+	"strconv"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// precisionChange returns the merged value and the reason it was chosen, and
+// true if update only changed how a number from origin is written (e.g.
+// `1.5` to `1.50`) without changing its value.
+func (m Visitor) precisionChange(nodes walk.Sources) (*yaml.RNode, string, bool) {
+	origin, ok := m.numberValue(nodes.Origin())
+	if !ok {
+		return nil, "", false
+	}
+	update, ok := m.numberValue(nodes.Updated())
+	if !ok || origin != update || nodes.Origin().YNode().Value == nodes.Updated().YNode().Value {
+		return nil, "", false
+	}
+	if dest, ok := m.numberValue(nodes.Dest()); ok && dest == origin && m.AdoptNumberFormatting {
+		return nodes.Updated(), "took update because it only changed the formatting of origin", true
+	}
+	return nodes.Dest(), "kept dest because update==origin numerically", true
+}
+
+// normalizeNumbers replaces the values of numeric nodes with a canonical
+// representation, so that numbers are compared by value.
+func (m Visitor) normalizeNumbers(nodes walk.Sources, values strValues) strValues {
+	for _, v := range []struct {
+		node  *yaml.RNode
+		value *string
+	}{
+		{nodes.Origin(), &values.Origin},
+		{nodes.Updated(), &values.Update},
+		{nodes.Dest(), &values.Dest},
+	} {
+		if s, ok := m.numberValue(v.node); ok {
+			*v.value = s
+		}
+	}
+	return values
+}
+
+// numberValue returns the representation of the value of node used to
+// compare numbers, and true if node is an int or float.
+func (m Visitor) numberValue(node *yaml.RNode) (string, bool) {
+	if m.CanonicalFloats {
+		return canonicalNumber(node)
+	}
+	return number(node)
+}
+
+// number returns the value of node written so that equal numbers are
+// written the same, and true if node is an int or float.  Ints which can't
+// be represented exactly as floats are written in decimal, so that they are
+// compared exactly rather than as their nearest float.  Values which are
+// strings, either because they are quoted (e.g. `"1.10"`) or explicitly
+// tagged (e.g. `!!str 1.10`), are never numbers, so that values such as
+// versions are compared exactly.
+func number(node *yaml.RNode) (string, bool) {
+	if yaml.IsMissingOrNull(node) || node.YNode().Kind != yaml.ScalarNode {
+		return "", false
+	}
+	// ShortTag resolves the tag from the value only if the node is neither
+	// tagged nor quoted
+	switch node.YNode().ShortTag() {
+	case yaml.NodeTagInt:
+		i, err := strconv.ParseInt(node.YNode().Value, 0, 64)
+		if err != nil {
+			return "", false
+		}
+		if f := float64(i); f < math.MaxInt64 && int64(f) == i {
+			return formatNumber(f), true
+		}
+		return strconv.FormatInt(i, 10), true
+	case yaml.NodeTagFloat:
+		f, err := strconv.ParseFloat(node.YNode().Value, 64)
+		if err != nil {
+			return "", false
+		}
+		return formatNumber(f), true
+	default:
+		return "", false
+	}
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
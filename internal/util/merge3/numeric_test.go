@@ -0,0 +1,180 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_NormalizeNumbers(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		visitor     Visitor
+		conflicts   int
+	}{
+		{
+			description: `precision change is adopted without normalization`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 1.50`,
+			local:       `ratio: 1.5`,
+			expected:    `ratio: 1.50`,
+		},
+		{
+			description: `precision change is ignored with normalization`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 1.50`,
+			local:       `ratio: 1.5`,
+			expected:    `ratio: 1.5`,
+			visitor:     Visitor{NormalizeNumbers: true},
+		},
+		{
+			description: `precision change keeps a dest change`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 1.50`,
+			local:       `ratio: 2`,
+			expected:    `ratio: 2`,
+			visitor:     Visitor{NormalizeNumbers: true, AdoptNumberFormatting: true},
+		},
+		{
+			description: `precision change adopts the update formatting`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 1.50`,
+			local:       `ratio: 1.5`,
+			expected:    `ratio: 1.50`,
+			visitor:     Visitor{NormalizeNumbers: true, AdoptNumberFormatting: true},
+		},
+		{
+			description: `dest precision change is not a conflict`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 2.5`,
+			local:       `ratio: 1.50`,
+			expected:    `ratio: 2.5`,
+			visitor:     Visitor{NormalizeNumbers: true},
+		},
+		{
+			description: `dest precision change is a conflict without normalization`,
+			origin:      `ratio: 1.5`,
+			update:      `ratio: 2.5`,
+			local:       `ratio: 1.50`,
+			expected:    `ratio: 2.5`,
+			conflicts:   1,
+		},
+		{
+			description: `large ints are compared exactly`,
+			origin:      `id: 9007199254740992`,
+			update:      `id: 9007199254740993`,
+			local:       `id: 9007199254740992`,
+			expected:    `id: 9007199254740993`,
+			visitor:     Visitor{NormalizeNumbers: true},
+		},
+		{
+			description: `ints are compared with floats numerically`,
+			origin:      `replicas: 2`,
+			update:      `replicas: 2.0`,
+			local:       `replicas: 3`,
+			expected:    `replicas: 3`,
+			visitor:     Visitor{NormalizeNumbers: true},
+		},
+		{
+			description: `strings are not normalized`,
+			origin:      `ratio: "1.5"`,
+			update:      `ratio: "1.50"`,
+			local:       `ratio: "1.5"`,
+			expected:    `ratio: "1.50"`,
+			visitor:     Visitor{NormalizeNumbers: true},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := tc.visitor.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.Len(t, report.Conflicts, tc.conflicts)
+		})
+	}
+}
+
+func TestVisitor_NormalizeNumbers_strings(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		drifted     bool
+	}{
+		{
+			description: `quoted versions are compared exactly`,
+			origin:      `version: "1.1"`,
+			update:      `version: "1.10"`,
+			local:       `version: "1.1"`,
+			expected:    `version: "1.10"`,
+		},
+		{
+			description: `single quoted versions are compared exactly`,
+			origin:      `version: '1.1'`,
+			update:      `version: '1.10'`,
+			local:       `version: '1.1'`,
+			expected:    `version: '1.10'`,
+		},
+		{
+			description: `tagged versions are compared exactly`,
+			origin:      `version: !!str 1.1`,
+			update:      `version: !!str 1.10`,
+			local:       `version: !!str 1.1`,
+			expected:    `version: !!str 1.10`,
+		},
+		{
+			description: `number changed to a string is a change`,
+			origin:      `version: 1.1`,
+			update:      `version: "1.10"`,
+			local:       `version: 1.1`,
+			expected:    `version: "1.10"`,
+		},
+		{
+			description: `dest string is a change from the origin number`,
+			origin:      `version: 1.1`,
+			update:      `version: 1.1`,
+			local:       `version: "1.10"`,
+			expected:    `version: "1.10"`,
+			drifted:     true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{NormalizeNumbers: true, AdoptNumberFormatting: true, ReportDrift: true}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.Equal(t, tc.drifted, report.Drift.Changed > 0)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// isOrderedSet returns true if the list at path is configured as an ordered
+// set and only contains scalars.
+func (m Visitor) isOrderedSet(nodes walk.Sources, path []string) bool {
+	if !containsPath(m.OrderedSetLists, path) {
+		return false
+	}
+	for _, node := range nodes {
+		if yaml.IsMissingOrNull(node) {
+			continue
+		}
+		for _, element := range node.Content() {
+			if element.Kind != yaml.ScalarNode {
+				return false
+			}
+		}
+	}
+	m.matchRule(pathRule("OrderedSetLists", path), path)
+	return true
+}
+
+// isContentIdentity returns true if the list at path has its elements
+// identified by their content.
+func (m Visitor) isContentIdentity(path []string) bool {
+	if identity, _ := m.listIdentity(path); identity == ListIdentityContent {
+		return true
+	}
+	if !containsPath(m.ContentIdentityLists, path) {
+		return false
+	}
+	m.matchRule(pathRule("ContentIdentityLists", path), path)
+	return true
+}
+
+// containsPath returns true if paths contains path.
+func containsPath(paths []string, path []string) bool {
+	p := pathString(path)
+	for i := range paths {
+		if paths[i] == p {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedUnion merges the lists as an ordered set of elements identified by
+// id.  The dest elements are kept in order, except those update removed from
+// origin, followed by the elements update added which dest doesn't already
+// have.
+func orderedUnion(nodes walk.Sources, id func(*yaml.Node) string) *yaml.RNode {
+	origin, update := idSet(nodes.Origin(), id), idSet(nodes.Updated(), id)
+
+	result := &yaml.Node{Kind: yaml.SequenceNode}
+	switch {
+	case !yaml.IsMissingOrNull(nodes.Dest()):
+		result.Style = nodes.Dest().YNode().Style
+	case !yaml.IsMissingOrNull(nodes.Updated()):
+		result.Style = nodes.Updated().YNode().Style
+	}
+
+	seen := sets.String{}
+	add := func(element *yaml.Node) {
+		if seen.Has(id(element)) {
+			return
+		}
+		seen.Insert(id(element))
+		e := *element
+		result.Content = append(result.Content, &e)
+	}
+	for _, element := range nodes.Dest().Content() {
+		if origin.Has(id(element)) && !update.Has(id(element)) {
+			// removed by update
+			continue
+		}
+		add(element)
+	}
+	for _, element := range nodes.Updated().Content() {
+		if origin.Has(id(element)) {
+			// not added by update -- dest may have removed it
+			continue
+		}
+		add(element)
+	}
+
+	if len(result.Content) == 0 && yaml.IsMissingOrNull(nodes.Dest()) {
+		return walk.ClearNode
+	}
+	return yaml.NewRNode(result)
+}
+
+// idSet returns the set of element ids in the list.
+func idSet(list *yaml.RNode, id func(*yaml.Node) string) sets.String {
+	values := sets.String{}
+	for _, element := range list.Content() {
+		values.Insert(id(element))
+	}
+	return values
+}
+
+// scalarID identifies a scalar element by its value.
+func scalarID(element *yaml.Node) string {
+	return element.Value
+}
+
+// contentID identifies an element by its content, ignoring comments, style
+// and the order of map fields.  Elements of any kind are identified, e.g.
+// the scalars of a list of strings.
+func contentID(element *yaml.Node) string {
+	id, err := Hash(yaml.NewRNode(element))
+	if err != nil {
+		// fall back to the element itself, which will not match any other
+		return fmt.Sprintf("%p", element)
+	}
+	return id
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_OrderedSetLists(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+	}{
+		{
+			description: `dest order is kept and update additions are appended`,
+			origin: `
+args: [--a, --b, --c]`,
+			update: `
+args: [--z, --a, --c, --y]`,
+			local: `
+args: [--c, --local, --a, --b]`,
+			expected: `
+args: [--c, --local, --a, --z, --y]`,
+		},
+		{
+			description: `elements removed from dest are not re-added`,
+			origin: `
+args:
+- --a
+- --b`,
+			update: `
+args:
+- --a
+- --b
+- --c`,
+			local: `
+args:
+- --b`,
+			expected: `
+args:
+- --b
+- --c`,
+		},
+		{
+			description: `elements added in both are not duplicated`,
+			origin: `
+args: [--a]`,
+			update: `
+args: [--a, --b, --b]`,
+			local: `
+args: [--b, --a]`,
+			expected: `
+args: [--b, --a]`,
+		},
+		{
+			description: `list added by update`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+args: [--a, --b]`,
+			local: `
+kind: Foo`,
+			expected: `
+kind: Foo
+args: [--a, --b]`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{OrderedSetLists: []string{"args"}}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_ContentIdentityLists(t *testing.T) {
+	origin := `
+kind: Foo
+rules:
+- from: a
+  to: b
+- from: b
+  to: c
+- from: c
+  to: d
+`
+	update := `
+kind: Foo
+rules:
+- from: a
+  to: b
+- from: c
+  to: d
+- from: d
+  to: e
+`
+	// dest reordered the rules, and rewrote one with its fields reordered
+	local := `
+kind: Foo
+rules:
+- from: c
+  to: d
+- to: b # local
+  from: a
+- from: b
+  to: c
+`
+
+	var testCases = []struct {
+		description string
+		paths       []string
+		expected    string
+	}{
+		{
+			description: `content identity`,
+			paths:       []string{"rules"},
+			expected: `
+kind: Foo
+rules:
+- from: c
+  to: d
+- to: b # local
+  from: a
+- from: d
+  to: e
+`,
+		},
+		{
+			description: `list is replaced without content identity`,
+			expected: `
+kind: Foo
+rules:
+- from: a
+  to: b
+- from: c
+  to: d
+- from: d
+  to: e
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{ContentIdentityLists: tc.paths}.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+
+	// reordering the elements in update is not a change
+	actual, _, err := Visitor{ContentIdentityLists: []string{"rules"}}.MergeStrings(local, origin, `
+kind: Foo
+rules:
+- from: c
+  to: d
+- from: b
+  to: c
+- from: a
+  to: b
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(local), strings.TrimSpace(actual))
+}
+
+func TestVisitor_ContentIdentityLists_scalars(t *testing.T) {
+	actual, _, err := Visitor{ContentIdentityLists: []string{"args"}}.
+		MergeStrings(`args: [a, b]`, `args: [a, b]`, `args: [b, a, c]`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `args: [a, b, c]`, strings.TrimSpace(actual))
+}
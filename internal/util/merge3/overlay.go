@@ -0,0 +1,245 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// OverlayEntry is a change to a single value, targeted by a JSONPath.
+type OverlayEntry struct {
+	// Path is the JSONPath of the value to change, e.g. `$.spec.replicas` or
+	// `$.spec.containers[?(@.name=="nginx")].image`.  Fields, list indexes
+	// and equality filters on list elements are supported.
+	Path string
+
+	// Value is the new value.  A nil Value removes the field or element.
+	Value *yaml.RNode
+}
+
+// MergeOverlay merges the changes described by overlay into dest.  The update
+// is the original with each overlay entry applied in order, so an overlay is
+// a concise way to describe small changes to original.  If original is nil
+// the overlay is applied to an empty map, or an empty list if dest is a
+// list, like Merge treats a nil original as empty.
+func (m Visitor) MergeOverlay(dest, original *yaml.RNode, overlay []OverlayEntry) (*yaml.RNode, *Report, error) {
+	var update *yaml.RNode
+	switch {
+	case !yaml.IsMissingOrNull(original):
+		update = original.Copy()
+	case dest != nil && dest.YNode().Kind == yaml.SequenceNode:
+		update = yaml.NewListRNode()
+	default:
+		update = yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+	}
+	for _, e := range overlay {
+		if err := applyOverlayEntry(update, e); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m.Merge(dest, original, update)
+}
+
+// applyOverlayEntry sets the value targeted by e in node.
+func applyOverlayEntry(node *yaml.RNode, e OverlayEntry) error {
+	path, err := parseJSONPath(e.Path)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		return errors.Errorf("JSONPath %q does not target a field", e.Path)
+	}
+
+	// look up the parent of the targeted value, creating the fields and list
+	// elements which are missing
+	p := node
+	for i, segment := range path[:len(path)-1] {
+		kind := yaml.MappingNode
+		if path[i+1].kind != jsonPathField {
+			kind = yaml.SequenceNode
+		}
+		if p, err = segment.lookupCreate(p, kind); err != nil {
+			return errors.WrapPrefixf(err, "%s", e.Path)
+		}
+	}
+
+	last := path[len(path)-1]
+	if err := last.checkParent(p); err != nil {
+		return errors.WrapPrefixf(err, "%s", e.Path)
+	}
+	switch last.kind {
+	case jsonPathIndex:
+		if last.index >= len(p.Content()) {
+			return errors.Errorf("JSONPath %q index is out of range", e.Path)
+		}
+		if e.Value == nil {
+			p.YNode().Content = append(p.Content()[:last.index], p.Content()[last.index+1:]...)
+		} else {
+			p.YNode().Content[last.index] = e.Value.YNode()
+		}
+		return nil
+	case jsonPathFilter:
+		setter := yaml.ElementSetter{Keys: []string{last.name}, Values: []string{last.value}}
+		if e.Value != nil {
+			setter.Element = e.Value.YNode()
+		}
+		_, err = p.Pipe(setter)
+		return err
+	default:
+		if e.Value == nil {
+			_, err = p.Pipe(yaml.Clear(last.name))
+			return err
+		}
+		return p.PipeE(yaml.SetField(last.name, e.Value))
+	}
+}
+
+// jsonPathSegmentKind identifies what a segment of a JSONPath targets.
+type jsonPathSegmentKind int
+
+const (
+	// jsonPathField is a field of a map, e.g. `.spec` or `['spec']`.
+	jsonPathField jsonPathSegmentKind = iota
+
+	// jsonPathFilter is the list element with a field value, e.g.
+	// `[?(@.name=="nginx")]`.
+	jsonPathFilter
+
+	// jsonPathIndex is the list element at an index, e.g. `[0]`.
+	jsonPathIndex
+)
+
+// jsonPathSegment is a segment of a parsed JSONPath.
+type jsonPathSegment struct {
+	kind jsonPathSegmentKind
+
+	// name is the name of the field, or of the field a filter matches.
+	name string
+
+	// value is the value a filter matches.
+	value string
+
+	// index is the list index.
+	index int
+}
+
+// checkParent returns an error if the segment can't target a value of p.
+func (s jsonPathSegment) checkParent(p *yaml.RNode) error {
+	if s.kind == jsonPathField {
+		if p.YNode().Kind != yaml.MappingNode {
+			return errors.Errorf("%s is not a field of a map", s)
+		}
+		return nil
+	}
+	if p.YNode().Kind != yaml.SequenceNode {
+		return errors.Errorf("%s is not an element of a list", s)
+	}
+	return nil
+}
+
+// lookupCreate returns the value of p targeted by the segment.  Missing
+// fields and elements are created as nodes of kind.
+func (s jsonPathSegment) lookupCreate(p *yaml.RNode, kind yaml.Kind) (*yaml.RNode, error) {
+	if err := s.checkParent(p); err != nil {
+		return nil, err
+	}
+	switch s.kind {
+	case jsonPathIndex:
+		if s.index >= len(p.Content()) {
+			return nil, errors.Errorf("index %d is out of range", s.index)
+		}
+		return yaml.NewRNode(p.Content()[s.index]), nil
+	case jsonPathFilter:
+		return p.Pipe(yaml.LookupCreate(kind, "["+s.name+"="+s.value+"]"))
+	default:
+		return p.Pipe(yaml.FieldMatcher{Name: s.name, Create: yaml.NewRNode(&yaml.Node{Kind: kind})})
+	}
+}
+
+// String returns the segment as it is written in a JSONPath.
+func (s jsonPathSegment) String() string {
+	switch s.kind {
+	case jsonPathIndex:
+		return "[" + strconv.Itoa(s.index) + "]"
+	case jsonPathFilter:
+		return "[?(@." + s.name + "==" + strconv.Quote(s.value) + ")]"
+	default:
+		return strconv.Quote(s.name)
+	}
+}
+
+// jsonPathFilterPattern matches an equality filter on list elements, e.g.
+// `[?(@.name=="nginx")]`.
+var jsonPathFilterPattern = regexp.MustCompile(`^\[\?\(@\.([^=\s]+)\s*==\s*(?:"([^"]*)"|'([^']*)')\)\]`)
+
+// jsonPathFieldPattern matches a bracketed field name, e.g.
+// `['app.kubernetes.io/name']`.
+var jsonPathFieldPattern = regexp.MustCompile(`^\[(?:"([^"]*)"|'([^']*)')\]`)
+
+// jsonPathIndexPattern matches a list index, e.g. `[0]`.
+var jsonPathIndexPattern = regexp.MustCompile(`^\[(-?\d+)\]`)
+
+// parseJSONPath returns the segments of the JSONPath p.  Only bracketed
+// numbers are list indexes, so that e.g. `.1` and `['1']` are fields.
+func parseJSONPath(p string) ([]jsonPathSegment, error) {
+	rest := strings.TrimPrefix(p, "$")
+	var path []jsonPathSegment
+	for rest != "" {
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("unsupported JSONPath %q", p)
+			}
+			path = append(path, jsonPathSegment{kind: jsonPathField, name: rest[:end]})
+			rest = rest[end:]
+			continue
+		}
+		if m := jsonPathFilterPattern.FindStringSubmatch(rest); m != nil {
+			path = append(path, jsonPathSegment{kind: jsonPathFilter, name: m[1], value: m[2] + m[3]})
+			rest = rest[len(m[0]):]
+			continue
+		}
+		if m := jsonPathFieldPattern.FindStringSubmatch(rest); m != nil {
+			path = append(path, jsonPathSegment{kind: jsonPathField, name: m[1] + m[2]})
+			rest = rest[len(m[0]):]
+			continue
+		}
+		if m := jsonPathIndexPattern.FindStringSubmatch(rest); m != nil {
+			i, err := strconv.Atoi(m[1])
+			if err != nil || i < 0 {
+				return nil, errors.Errorf("JSONPath %q has an invalid index %s", p, m[1])
+			}
+			path = append(path, jsonPathSegment{kind: jsonPathIndex, index: i})
+			rest = rest[len(m[0]):]
+			continue
+		}
+		if len(path) == 0 && !strings.HasPrefix(p, "$") && !strings.HasPrefix(rest, "[") {
+			// allow the leading `$.` to be omitted
+			rest = "." + rest
+			continue
+		}
+		return nil, errors.Errorf("unsupported JSONPath %q", p)
+	}
+	return path, nil
+}
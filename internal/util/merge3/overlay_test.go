@@ -0,0 +1,211 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_MergeOverlay(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app.kubernetes.io/name: app
+    app.kubernetes.io/part-of: shop
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+      - name: sidecar
+        image: sidecar:1.0
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app.kubernetes.io/name: app
+    app.kubernetes.io/part-of: shop
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [--verbose]
+      - name: sidecar
+        image: sidecar:2.0
+`
+	overlay := []OverlayEntry{
+		{Path: `$.spec.replicas`, Value: yaml.NewScalarRNode("3")},
+		{Path: `$.spec.template.spec.containers[?(@.name=="nginx")].image`, Value: yaml.NewScalarRNode("nginx:1.8")},
+		{Path: `$.metadata.labels['app.kubernetes.io/part-of']`},
+	}
+	expected := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app.kubernetes.io/name: app
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [--verbose]
+      - name: sidecar
+        image: sidecar:2.0
+`
+
+	actual, _, err := Visitor{}.MergeOverlay(yaml.MustParse(local), yaml.MustParse(origin), overlay)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual.MustString()))
+
+	// the original is not modified by the overlay
+	o := yaml.MustParse(origin)
+	_, _, err = Visitor{}.MergeOverlay(yaml.MustParse(local), o, overlay)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(origin), strings.TrimSpace(o.MustString()))
+}
+
+func TestVisitor_MergeOverlay_nilOriginal(t *testing.T) {
+	// the overlay is applied to an empty original
+	actual, _, err := Visitor{}.MergeOverlay(yaml.MustParse("a: b\n"), nil,
+		[]OverlayEntry{{Path: `$.c.d`, Value: yaml.NewScalarRNode("e")}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "a: b\nc:\n  d: e\n", actual.MustString())
+
+	// of dest's kind, so an index into a list root is out of range rather
+	// than into a map
+	actual, _, err = Visitor{}.MergeOverlay(yaml.MustParse("- a\n"), nil,
+		[]OverlayEntry{{Path: `$[0]`, Value: yaml.NewScalarRNode("b")}})
+	assert.EqualError(t, err, `JSONPath "$[0]" index is out of range`)
+	assert.Nil(t, actual)
+}
+
+func TestVisitor_MergeOverlay_error(t *testing.T) {
+	_, _, err := Visitor{}.MergeOverlay(yaml.MustParse("a: b"), yaml.MustParse("a: b"),
+		[]OverlayEntry{{Path: `$..a`, Value: yaml.NewScalarRNode("c")}})
+	assert.EqualError(t, err, `unsupported JSONPath "$..a"`)
+}
+
+func TestVisitor_MergeOverlay_paths(t *testing.T) {
+	origin := `
+data:
+  "1": a
+  items: [a, b]
+`
+	testCases := []struct {
+		description string
+		path        string
+		value       string
+		expected    string
+		err         string
+	}{
+		{
+			description: `an index sets a list element`,
+			path:        `$.data.items[1]`,
+			value:       `c`,
+			expected: `
+data:
+  "1": a
+  items: [a, c]
+`,
+		},
+		{
+			description: `a quoted number is a field`,
+			path:        `$.data['1']`,
+			value:       `b`,
+			expected: `
+data:
+  "1": b
+  items: [a, b]
+`,
+		},
+		{
+			description: `a dotted number is a field`,
+			path:        `$.data.-1`,
+			value:       `b`,
+			expected: `
+data:
+  "1": a
+  items: [a, b]
+  -1: b
+`,
+		},
+		{
+			description: `a negative index is rejected`,
+			path:        `$.data.items[-1]`,
+			value:       `c`,
+			err:         `JSONPath "$.data.items[-1]" has an invalid index -1`,
+		},
+		{
+			description: `an index into a map is rejected`,
+			path:        `$.data[0]`,
+			value:       `c`,
+			err:         `$.data[0]: [0] is not an element of a list`,
+		},
+		{
+			description: `an index into a nested map is rejected`,
+			path:        `$.data[0].name`,
+			value:       `c`,
+			err:         `$.data[0].name: [0] is not an element of a list`,
+		},
+		{
+			description: `an index out of range is rejected`,
+			path:        `$.data.items[2]`,
+			value:       `c`,
+			err:         `JSONPath "$.data.items[2]" index is out of range`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{}.MergeOverlay(yaml.MustParse(origin), yaml.MustParse(origin),
+				[]OverlayEntry{{Path: tc.path, Value: yaml.NewScalarRNode(tc.value)}})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual.MustString()))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// PatchOperation sets the field at Path to Value, or removes it if Value is
+// nil.  Path contains the segments of the path to the field, e.g.
+// `[spec containers [name=nginx] image]`.
+type PatchOperation struct {
+	Path  []string
+	Value *yaml.RNode
+}
+
+// Patch is a list of operations which are applied in order.
+type Patch []PatchOperation
+
+// recordReverse records an operation restoring the dest value of the field
+// at path in Report.ReversePatch, if the merged value node isn't dest.
+func (m Visitor) recordReverse(path []string, nodes walk.Sources, node *yaml.RNode) {
+	if !m.ReversePatch || node == nodes.Dest() ||
+		yaml.IsMissingOrNull(node) && nodes.Dest() == nil {
+		return
+	}
+	op := PatchOperation{Path: append([]string{}, path...)}
+	if nodes.Dest() != nil {
+		// restore a null dest value too, since the merge removes it
+		op.Value = nodes.Dest().Copy()
+	}
+	m.report.ReversePatch = append(m.report.ReversePatch, op)
+}
+
+// Apply applies the operations of the patch to node.  Maps, lists and list
+// elements on the path to a field are created if missing, and removed if
+// removing the field leaves them empty.  Fields and elements which are
+// missing from node are appended, so a field restored by a reverse patch
+// may move to the end of its map or list.
+func (p Patch) Apply(node *yaml.RNode) {
+	for _, op := range p {
+		if len(op.Path) == 0 {
+			continue
+		}
+		// parents[i] is the parent of the node at op.Path[i]
+		parents := []*yaml.Node{node.YNode()}
+		for i, segment := range op.Path[:len(op.Path)-1] {
+			kind := yaml.MappingNode
+			if strings.HasPrefix(op.Path[i+1], "[") {
+				kind = yaml.SequenceNode
+			}
+			child := lookupSegment(parents[i], segment, op.Value != nil, kind)
+			if child == nil {
+				// nothing to remove
+				break
+			}
+			parents = append(parents, child)
+		}
+		if len(parents) < len(op.Path) {
+			continue
+		}
+		last := op.Path[len(op.Path)-1]
+		if op.Value != nil {
+			setSegment(parents[len(parents)-1], last, op.Value.YNode())
+			continue
+		}
+		removeSegment(parents[len(parents)-1], last)
+		for i := len(parents) - 1; i > 0; i-- {
+			if len(parents[i].Content) > 0 {
+				break
+			}
+			removeChild(parents[i-1], parents[i])
+		}
+	}
+}
+
+// lookupSegment returns the child of node identified by the path segment,
+// creating it with kind if it is missing and create is set.
+func lookupSegment(node *yaml.Node, segment string, create bool, kind yaml.Kind) *yaml.Node {
+	if i := segmentIndex(node, segment, kind); i >= 0 {
+		return node.Content[i]
+	}
+	if !create {
+		return nil
+	}
+	child := &yaml.Node{Kind: kind}
+	if !strings.HasPrefix(segment, "[") {
+		node.Kind = yaml.MappingNode
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: segment}, child)
+		return child
+	}
+	// a new element is a map with its merge key fields
+	node.Kind = yaml.SequenceNode
+	for _, kv := range segmentKeyValues(segment) {
+		child.Content = append(child.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: kv[0]},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: kv[1]})
+	}
+	node.Content = append(node.Content, child)
+	return child
+}
+
+// setSegment sets the child of node identified by the segment to value.
+func setSegment(node *yaml.Node, segment string, value *yaml.Node) {
+	if i := segmentIndex(node, segment, value.Kind); i >= 0 {
+		node.Content[i] = value
+		return
+	}
+	if strings.HasPrefix(segment, "[") {
+		node.Kind = yaml.SequenceNode
+		node.Content = append(node.Content, value)
+		return
+	}
+	node.Kind = yaml.MappingNode
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: segment}, value)
+}
+
+// removeSegment removes the child of node identified by the segment.
+func removeSegment(node *yaml.Node, segment string) {
+	i := segmentIndex(node, segment, yaml.ScalarNode)
+	if i < 0 {
+		i = segmentIndex(node, segment, yaml.MappingNode)
+	}
+	switch {
+	case i < 0:
+		return
+	case node.Kind == yaml.MappingNode:
+		node.Content = append(node.Content[:i-1], node.Content[i+1:]...)
+	default:
+		node.Content = append(node.Content[:i], node.Content[i+1:]...)
+	}
+}
+
+// removeChild removes the child node from the content of node.
+func removeChild(node, child *yaml.Node) {
+	for i := range node.Content {
+		if node.Content[i] != child {
+			continue
+		}
+		if node.Kind == yaml.MappingNode {
+			node.Content = append(node.Content[:i-1], node.Content[i+1:]...)
+		} else {
+			node.Content = append(node.Content[:i], node.Content[i+1:]...)
+		}
+		return
+	}
+}
+
+// segmentIndex returns the index in the content of node of the child
+// identified by the segment, or -1 if node doesn't have it.  Element
+// segments identify scalar elements by their value if kind is a scalar, and
+// map elements by their merge key values otherwise.
+func segmentIndex(node *yaml.Node, segment string, kind yaml.Kind) int {
+	if !strings.HasPrefix(segment, "[") {
+		if node.Kind != yaml.MappingNode {
+			return -1
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return i + 1
+			}
+		}
+		return -1
+	}
+	if node.Kind != yaml.SequenceNode {
+		return -1
+	}
+	value := segment[1 : len(segment)-1]
+	for i, element := range node.Content {
+		if kind == yaml.ScalarNode {
+			if element.Kind == yaml.ScalarNode && element.Value == value {
+				return i
+			}
+			continue
+		}
+		if element.Kind == yaml.MappingNode && hasKeyValues(element, segmentKeyValues(segment)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// segmentKeyValues returns the merge key fields and values of an element
+// segment, e.g. `[name=nginx]`.
+func segmentKeyValues(segment string) [][2]string {
+	var kvs [][2]string
+	for _, part := range strings.Split(segment[1:len(segment)-1], ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 {
+			kvs = append(kvs, [2]string{kv[0], kv[1]})
+		}
+	}
+	return kvs
+}
+
+// hasKeyValues returns true if the fields of element have the merge key
+// values.
+func hasKeyValues(element *yaml.Node, kvs [][2]string) bool {
+	for _, kv := range kvs {
+		if value, found := fieldValue(element, kv[0]); !found || value != kv[1] {
+			return false
+		}
+	}
+	return len(kvs) > 0
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_ReversePatch(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+	}{
+		{
+			description: `changed, added and removed fields`,
+			origin: `
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+  paused: false
+  strategy:
+    type: Recreate
+`,
+			update: `
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: web
+spec:
+  replicas: 2
+  minReadySeconds: 5
+`,
+			local: `
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  paused: false
+  strategy:
+    type: Recreate
+`,
+		},
+		{
+			description: `list elements`,
+			origin: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a, b]
+      - name: logger
+        image: logger:1.0
+`,
+			update: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, c]
+      - name: sidecar
+        image: sidecar:1.0
+`,
+			local: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a, b]
+      - name: logger
+        image: logger:1.0
+`,
+		},
+		{
+			description: `associative list deleted by update`,
+			origin: `
+kind: Foo
+x: 1
+l:
+- name: a
+  v: 1
+`,
+			update: `
+kind: Foo
+x: 1
+`,
+			local: `
+kind: Foo
+x: 1
+l:
+- name: a
+  v: 1
+`,
+		},
+		{
+			description: `map nulled by update`,
+			origin: `
+kind: Foo
+x: 1
+m:
+  a: 1
+`,
+			update: `
+kind: Foo
+x: 1
+m: null
+`,
+			local: `
+kind: Foo
+x: 1
+m:
+  a: 1
+`,
+		},
+		{
+			description: `map nulled by dest`,
+			origin: `
+kind: Foo
+x: 1
+m:
+  a: 1
+`,
+			update: `
+kind: Foo
+x: 1
+m:
+  a: 2
+`,
+			local: `
+kind: Foo
+x: 1
+m: null
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			result := yaml.MustParse(tc.local)
+			_, report, err := Visitor{ReversePatch: true, InferAssociativeLists: true}.
+				Merge(result, yaml.MustParse(tc.origin), yaml.MustParse(tc.update))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.NotEmpty(t, report.ReversePatch)
+
+			// applying the reverse patch to the result restores dest
+			report.ReversePatch.Apply(result)
+			expected, err := Hash(yaml.MustParse(tc.local))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual, err := Hash(result)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, expected, actual, result.MustString())
+		})
+	}
+}
+
+func TestPatch_Apply(t *testing.T) {
+	node := yaml.MustParse(`
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+`)
+	Patch{
+		{Path: []string{"spec", "items", "[name=a]", "value"}},
+		{Path: []string{"spec", "items", "[name=a]", "name"}},
+		{Path: []string{"spec", "replicas"}, Value: yaml.NewScalarRNode("3")},
+		{Path: []string{"metadata", "labels", "app"}, Value: yaml.NewScalarRNode("web")},
+		{Path: []string{"status", "phase"}},
+	}.Apply(node)
+	assert.Equal(t, strings.TrimSpace(`
+kind: Foo
+spec:
+  replicas: 3
+metadata:
+  labels:
+    app: web
+`), strings.TrimSpace(node.MustString()))
+}
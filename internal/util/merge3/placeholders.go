@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// checkPlaceholders records a warning for each scalar in the value node
+// update added at path which matches the Placeholders pattern, since the
+// consumer is meant to replace it.
+func (m Visitor) checkPlaceholders(path []string, node *yaml.RNode) {
+	if m.Placeholders == nil || m.report == nil || yaml.IsMissingOrNull(node) {
+		return
+	}
+	var check func(n *yaml.Node)
+	check = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && m.Placeholders.MatchString(n.Value) {
+			m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+				"%s was added with the placeholder value %q, which must be replaced",
+				pathString(path), n.Value))
+		}
+		for i, c := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 {
+				// skip the keys
+				continue
+			}
+			check(c)
+		}
+	}
+	check(node.YNode())
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"regexp"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_Placeholders(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  project: REPLACE_ME
+`
+	update := `
+kind: Foo
+spec:
+  project: REPLACE_ME
+  region: REPLACE_ME
+  zone: us-east1-b
+  args: [--token, "${TOKEN}"]
+  database:
+    host: REPLACE_ME
+`
+	local := `
+kind: Foo
+spec:
+  project: my-project
+`
+
+	v := Visitor{Placeholders: regexp.MustCompile(`^REPLACE_ME$|^\$\{.+\}$`)}
+	_, report, err := v.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		`spec.args was added with the placeholder value "${TOKEN}", which must be replaced`,
+		`spec.database.host was added with the placeholder value "REPLACE_ME", which must be replaced`,
+		`spec.region was added with the placeholder value "REPLACE_ME", which must be replaced`,
+	}, report.Warnings)
+
+	// placeholders are only detected when configured
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.Warnings)
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// protectComment returns the dest comment if it is a ProtectedComment and
+// the merged comment is empty, e.g. because update removed it, and
+// otherwise returns the merged comment.
+func (m Visitor) protectComment(dest, merged string) string {
+	if m.ProtectedComments == nil || merged != "" || !m.ProtectedComments.MatchString(dest) {
+		return merged
+	}
+	return dest
+}
+
+// protectComments keeps the ProtectedComments of the dest field value on
+// the merged value.
+func (m Visitor) protectComments(dest, merged *yaml.RNode) {
+	if m.ProtectedComments == nil || yaml.IsMissingOrNull(dest) || yaml.IsMissingOrNull(merged) {
+		return
+	}
+	d, n := dest.YNode(), merged.YNode()
+	n.HeadComment = m.protectComment(d.HeadComment, n.HeadComment)
+	n.LineComment = m.protectComment(d.LineComment, n.LineComment)
+	n.FootComment = m.protectComment(d.FootComment, n.FootComment)
+}
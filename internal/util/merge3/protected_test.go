@@ -0,0 +1,147 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ProtectedComments(t *testing.T) {
+	setters := regexp.MustCompile(`\$kpt-set`)
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		protected   *regexp.Regexp
+	}{
+		{
+			description: `setter survives an upstream comment removal`,
+			origin: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			update: `
+spec:
+  image: nginx:1.7`,
+			local: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			expected: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			protected: setters,
+		},
+		{
+			description: `setter survives an upstream value change`,
+			origin: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			update: `
+spec:
+  image: nginx:1.8`,
+			local: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			expected: `
+spec:
+  image: nginx:1.8 # {"$kpt-set":"image"}`,
+			protected: setters,
+		},
+		{
+			description: `setter on a key survives`,
+			origin: `
+spec:
+  # {"$kpt-set":"replicas"}
+  replicas: 1`,
+			update: `
+spec:
+  replicas: 1`,
+			local: `
+spec:
+  # {"$kpt-set":"replicas"}
+  replicas: 3`,
+			expected: `
+spec:
+  # {"$kpt-set":"replicas"}
+  replicas: 3`,
+			protected: setters,
+		},
+		{
+			description: `other comments are removed`,
+			origin: `
+spec:
+  image: nginx:1.7 # the image`,
+			update: `
+spec:
+  image: nginx:1.7`,
+			local: `
+spec:
+  image: nginx:1.7 # the image`,
+			expected: `
+spec:
+  image: nginx:1.7`,
+			protected: setters,
+		},
+		{
+			description: `new update comments are taken`,
+			origin: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			update: `
+spec:
+  image: nginx:1.8 # {"$kpt-set":"nginx-image"}`,
+			local: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			expected: `
+spec:
+  image: nginx:1.8 # {"$kpt-set":"nginx-image"}`,
+			protected: setters,
+		},
+		{
+			description: `setter is removed without the option`,
+			origin: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			update: `
+spec:
+  image: nginx:1.7`,
+			local: `
+spec:
+  image: nginx:1.7 # {"$kpt-set":"image"}`,
+			expected: `
+spec:
+  image: nginx:1.7`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{ProtectedComments: tc.protected}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// DefaultMergedAtAnnotation is the default annotation recording when a
+	// resource was changed by a merge.
+	DefaultMergedAtAnnotation = "kpt.dev/merged-at"
+
+	// DefaultMergeChangesAnnotation is the default annotation recording the
+	// number of fields a merge changed in a resource.
+	DefaultMergeChangesAnnotation = "kpt.dev/merge-changes"
+)
+
+// Provenance configures the annotations a Registry adds to the resources a
+// merge changed, so that downstream automation can detect merge activity.
+type Provenance struct {
+	// MergedAt is the annotation set to the time of the merge.
+	// Defaults to DefaultMergedAtAnnotation.
+	MergedAt string
+
+	// Changes is the annotation set to the number of fields the merge
+	// changed.  Defaults to DefaultMergeChangesAnnotation.
+	Changes string
+
+	// Now returns the time of the merge.  Defaults to time.Now.
+	Now func() time.Time
+}
+
+// annotate sets the provenance annotations on result if the merge changed
+// any of the fields of dest.
+func (p Provenance) annotate(dest, result *yaml.RNode) error {
+	if result == nil {
+		return nil
+	}
+	n := countChanges(dest, result)
+	if n == 0 {
+		return nil
+	}
+	if p.MergedAt == "" {
+		p.MergedAt = DefaultMergedAtAnnotation
+	}
+	if p.Changes == "" {
+		p.Changes = DefaultMergeChangesAnnotation
+	}
+	if p.Now == nil {
+		p.Now = time.Now
+	}
+	if err := result.PipeE(yaml.SetAnnotation(p.MergedAt, p.Now().UTC().Format(time.RFC3339))); err != nil {
+		return err
+	}
+	return result.PipeE(yaml.SetAnnotation(p.Changes, strconv.Itoa(n)))
+}
+
+// countChanges returns the number of fields which differ between dest and
+// result.  Maps are compared field by field, other values as a whole.
+func countChanges(dest, result *yaml.RNode) int {
+	if yaml.IsMissingOrNull(dest) || yaml.IsMissingOrNull(result) ||
+		dest.YNode().Kind != yaml.MappingNode || result.YNode().Kind != yaml.MappingNode {
+		if displayValue(dest) == displayValue(result) {
+			return 0
+		}
+		return 1
+	}
+
+	var n int
+	fields := map[string]bool{}
+	for _, node := range []*yaml.RNode{dest, result} {
+		_ = node.VisitFields(func(f *yaml.MapNode) error {
+			field := f.Key.YNode().Value
+			if fields[field] {
+				return nil
+			}
+			fields[field] = true
+			n += countChanges(mapFieldValue(dest, field), mapFieldValue(result, field))
+			return nil
+		})
+	}
+	return n
+}
+
+// mapFieldValue returns the value of field in node, or nil if it isn't set.
+func mapFieldValue(node *yaml.RNode, field string) *yaml.RNode {
+	f := node.Field(field)
+	if f == nil {
+		return nil
+	}
+	return f.Value
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Provenance(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+data:
+  a: origin
+  b: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: origin
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+data:
+  a: update
+  b: update
+  c: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: origin
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+data:
+  a: origin
+  b: local
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: local
+`
+	now := func() time.Time { return time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC) }
+
+	var testCases = []struct {
+		description string
+		provenance  *Provenance
+		expected    string
+	}{
+		{
+			description: `default annotations`,
+			provenance:  &Provenance{Now: now},
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+  annotations:
+    kpt.dev/merged-at: '2021-03-04T05:06:07Z'
+    kpt.dev/merge-changes: '3'
+data:
+  a: update
+  b: update
+  c: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: local
+`,
+		},
+		{
+			description: `configured annotations`,
+			provenance:  &Provenance{MergedAt: "example.com/at", Changes: "example.com/changes", Now: now},
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+  annotations:
+    example.com/at: '2021-03-04T05:06:07Z'
+    example.com/changes: '3'
+data:
+  a: update
+  b: update
+  c: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: local
+`,
+		},
+		{
+			description: `no annotations without the option`,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+data:
+  a: update
+  b: update
+  c: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  a: local
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Registry{Provenance: tc.provenance}.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
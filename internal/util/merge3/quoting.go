@@ -0,0 +1,68 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// unquotedValues replaces the values of the nodes with their values stripped
+// of quoting, so that e.g. `"80"` and `80`, or `[a, "b"]` and `[a, b]`, are
+// compared as equal.
+func (m Visitor) unquotedValues(nodes walk.Sources, values strValues) (strValues, error) {
+	for _, v := range []struct {
+		node  *yaml.RNode
+		value *string
+	}{
+		{nodes.Origin(), &values.Origin},
+		{nodes.Updated(), &values.Update},
+		{nodes.Dest(), &values.Dest},
+	} {
+		if yaml.IsMissingOrNull(v.node) {
+			continue
+		}
+		n := v.node.Copy()
+		m.stripQuoting(n.YNode())
+		n.YNode().Style = yaml.FlowStyle
+		s, err := n.String()
+		if err != nil {
+			return strValues{}, err
+		}
+		*v.value = s
+	}
+	return values, nil
+}
+
+// stripQuoting recursively removes the quoting and the standard tags of the
+// scalars in node, so that their values are written the same way.
+func (m Visitor) stripQuoting(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		// only numbers are normalized, so check the tag before the quoting
+		// is removed
+		if n, ok := m.numberValue(yaml.NewRNode(node)); ok && m.NormalizeNumbers {
+			node.Value = n
+		}
+		node.Style = 0
+		if strings.HasPrefix(node.Tag, "!!") {
+			node.Tag = ""
+		}
+	}
+	for _, n := range node.Content {
+		m.stripQuoting(n)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_IgnoreQuoting(t *testing.T) {
+	var testCases = []struct {
+		description   string
+		origin        string
+		update        string
+		local         string
+		expected      string
+		conflicts     int
+		ignoreQuoting bool
+	}{
+		{
+			description: `quoting changed in update keeps dest`,
+			origin: `
+port: 80
+enabled: true`,
+			update: `
+port: "80"
+enabled: 'true'`,
+			local: `
+port: 80
+enabled: true`,
+			expected: `
+port: 80
+enabled: true`,
+			ignoreQuoting: true,
+		},
+		{
+			description: `quoting changed in a list in update keeps dest`,
+			origin: `
+args: [--port, 80]`,
+			update: `
+args: [--port, "80"]`,
+			local: `
+args: [--port, 80, --verbose]`,
+			expected: `
+args: [--port, 80, --verbose]`,
+			ignoreQuoting: true,
+		},
+		{
+			description: `quoting changed in a list in update is taken without the option`,
+			origin: `
+args: [--port, 80]`,
+			update: `
+args: [--port, "80"]`,
+			local: `
+args: [--port, 80, --verbose]`,
+			expected: `
+args: [--port, "80"]`,
+			conflicts: 1,
+		},
+		{
+			description: `quoting changed in a list in dest doesn't conflict`,
+			origin: `
+args: [--port, 80]`,
+			update: `
+args: [--port, 81]`,
+			local: `
+args: ['--port', "80"]`,
+			expected: `
+args: [--port, 81]`,
+			ignoreQuoting: true,
+		},
+		{
+			description: `quoting changed in a list in dest conflicts without the option`,
+			origin: `
+args: [--port, 80]`,
+			update: `
+args: [--port, 81]`,
+			local: `
+args: ['--port', "80"]`,
+			expected: `
+args: [--port, 81]`,
+			conflicts: 1,
+		},
+		{
+			description: `dest quoting of a list is kept for equal values`,
+			origin: `
+args: [--port, 79]`,
+			update: `
+args: [--port, 80]`,
+			local: `
+args: [--port, "80"]`,
+			expected: `
+args: [--port, "80"]`,
+			ignoreQuoting: true,
+		},
+		{
+			description: `dest quoting is kept for equal values`,
+			origin: `
+port: 79`,
+			update: `
+port: 80`,
+			local: `
+port: "80"`,
+			expected: `
+port: "80"`,
+			ignoreQuoting: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{IgnoreQuoting: tc.ignoreQuoting}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.Len(t, report.Conflicts, tc.conflicts)
+		})
+	}
+}
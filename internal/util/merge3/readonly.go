@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// readOnlyHint is the comment with which dest declares that a field or an
+// associative list element must not be changed by update, e.g.
+// `image: nginx:1.7 # readonly`.
+const readOnlyHint = "readonly"
+
+// ReadOnlyFieldPolicy controls how the fields and list elements dest marked
+// with a `# readonly` comment are merged.
+type ReadOnlyFieldPolicy int
+
+const (
+	// ReadOnlyIgnore ignores the comment, and merges the field like any
+	// other.
+	ReadOnlyIgnore ReadOnlyFieldPolicy = iota
+
+	// ReadOnlyKeepDest keeps the dest value of the field.
+	ReadOnlyKeepDest
+
+	// ReadOnlyKeepDestAndWarn keeps the dest value of the field, and records
+	// a warning if update changed it.
+	ReadOnlyKeepDestAndWarn
+)
+
+// readOnly returns true if the dest field, with the given key and value, is
+// marked with a `# readonly` comment.  Only dest can mark a field, since the
+// comment protects local changes from upstream.
+func (m Visitor) readOnly(keys, values walk.Sources) bool {
+	if m.ReadOnlyFields == ReadOnlyIgnore || len(keys) <= walk.DestIndex || len(values) <= walk.DestIndex {
+		return false
+	}
+	for _, node := range []*yaml.RNode{keys[walk.DestIndex], values[walk.DestIndex]} {
+		if !yaml.IsMissingOrNull(node) && hasReadOnlyHint(node.YNode()) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyElement returns true if the dest element of an associative list is
+// marked with a `# readonly` comment, either on the line before the element
+// or on its first line, e.g.
+//
+//	# readonly
+//	- name: nginx
+//
+// The comments are parsed onto the first key of the element, or onto the
+// first value if they follow it.
+func (m Visitor) readOnlyElement(elements walk.Sources) bool {
+	if m.ReadOnlyFields == ReadOnlyIgnore || len(elements) <= walk.DestIndex {
+		return false
+	}
+	dest := elements[walk.DestIndex]
+	if yaml.IsMissingOrNull(dest) || dest.YNode().Kind != yaml.MappingNode {
+		return false
+	}
+	if hasReadOnlyHint(dest.YNode()) {
+		return true
+	}
+	content := dest.YNode().Content
+	return len(content) > 1 && (hasReadOnlyHint(content[0]) || isReadOnlyHint(content[1].LineComment))
+}
+
+// keepReadOnlyComment returns a copy of the readonly dest element with its
+// `# readonly` comment moved to where the comment is written from, so that
+// the element is written with the comment where dest had it.  The comment
+// on the line before the element is parsed onto the head of the first key
+// but written from the head of the element, and the comment on the first
+// line is parsed onto the line of the first key but written from its head.
+func keepReadOnlyComment(element *yaml.RNode) *yaml.RNode {
+	if yaml.IsMissingOrNull(element) || len(element.YNode().Content) < 2 {
+		return element
+	}
+	node := yaml.CopyYNode(element.YNode())
+	key := node.Content[0]
+	if isReadOnlyHint(key.HeadComment) {
+		node.HeadComment = strings.TrimPrefix(node.HeadComment+"\n"+key.HeadComment, "\n")
+		key.HeadComment = ""
+	}
+	if isReadOnlyHint(key.LineComment) && key.HeadComment == "" {
+		key.HeadComment, key.LineComment = key.LineComment, ""
+	}
+	return yaml.NewRNode(node)
+}
+
+// hasReadOnlyHint returns true if the head or line comment of node is a
+// `# readonly` comment.
+func hasReadOnlyHint(node *yaml.Node) bool {
+	return isReadOnlyHint(node.HeadComment) || isReadOnlyHint(node.LineComment)
+}
+
+// isReadOnlyHint returns true if a line of comment is a `# readonly` comment.
+func isReadOnlyHint(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")) == readOnlyHint {
+			return true
+		}
+	}
+	return false
+}
+
+// visitReadOnly keeps the dest value of a field or element marked with a
+// `# readonly` comment.
+func (m Visitor) visitReadOnly(nodes walk.Sources, path []string) (*yaml.RNode, error) {
+	m.matchRule("# "+readOnlyHint, path)
+	if m.ReadOnlyFields == ReadOnlyKeepDestAndWarn && m.report != nil {
+		origin, update := displayValue(nodes.Origin()), displayValue(nodes.Updated())
+		if origin != update && update != displayValue(nodes.Dest()) {
+			m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+				"%s is marked readonly in dest, so the change by update to %q was not merged",
+				pathString(path), update))
+		}
+	}
+	return m.decide(path, nodes.Dest(), "kept dest because dest marked it readonly")
+}
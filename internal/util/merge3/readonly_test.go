@@ -0,0 +1,233 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ReadOnlyFields(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7
+  args: [--verbose]
+  selector:
+    app: nginx
+`
+	update := `
+kind: Deployment
+spec:
+  replicas: 2
+  image: nginx:1.8
+  args: [--quiet]
+  selector:
+    app: nginx
+    tier: web
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7 # readonly
+  # readonly
+  args: [--verbose]
+  # readonly
+  selector:
+    app: nginx
+`
+
+	testCases := []struct {
+		description string
+		policy      ReadOnlyFieldPolicy
+		expected    string
+		warnings    []string
+		conflicts   []string
+	}{
+		{
+			description: `readonly fields keep dest`,
+			policy:      ReadOnlyKeepDest,
+			expected: `
+kind: Deployment
+spec:
+  replicas: 2
+  image: nginx:1.7 # readonly
+  # readonly
+  args: [--verbose]
+  # readonly
+  selector:
+    app: nginx
+`,
+		},
+		{
+			description: `suppressed changes are warned about`,
+			policy:      ReadOnlyKeepDestAndWarn,
+			expected: `
+kind: Deployment
+spec:
+  replicas: 2
+  image: nginx:1.7 # readonly
+  # readonly
+  args: [--verbose]
+  # readonly
+  selector:
+    app: nginx
+`,
+			warnings: []string{
+				`spec.args is marked readonly in dest, so the change by update to "[--quiet]" was not merged`,
+				`spec.image is marked readonly in dest, so the change by update to "nginx:1.8" was not merged`,
+				`spec.selector is marked readonly in dest, so the change by update to "{app: nginx, tier: web}" was not merged`,
+			},
+		},
+		{
+			description: `readonly comments are ignored`,
+			policy:      ReadOnlyIgnore,
+			expected: `
+kind: Deployment
+spec:
+  replicas: 2
+  image: nginx:1.8
+  # readonly
+  args: [--quiet]
+  # readonly
+  selector:
+    app: nginx
+    tier: web
+`,
+			// the line comment is a change to the dest value
+			conflicts: []string{"spec.image"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{ReadOnlyFields: tc.policy}.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.ElementsMatch(t, tc.warnings, report.Warnings)
+			var conflicts []string
+			for _, c := range report.Conflicts {
+				conflicts = append(conflicts, c.Path)
+			}
+			assert.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+
+	// the comment is ignored unless a policy enforces it
+	actual, _, err := Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, actual, "image: nginx:1.8")
+
+	// the comment is only honoured in dest
+	actual, _, err = Visitor{ReadOnlyFields: ReadOnlyKeepDest}.MergeStrings(origin, origin, strings.Replace(update, "nginx:1.8", "nginx:1.8 # readonly", 1))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, actual, "image: nginx:1.8 # readonly")
+}
+
+func TestVisitor_ReadOnlyFields_elements(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:1.0
+  - name: proxy
+    image: proxy:1.0
+`
+	update := `
+kind: Deployment
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.8
+  - name: sidecar
+    image: sidecar:1.1
+  - name: proxy
+    image: proxy:1.1
+`
+	local := `
+kind: Deployment
+spec:
+  containers:
+  # readonly
+  - name: nginx
+    image: nginx:1.7
+  - # readonly
+    name: sidecar
+    image: sidecar:1.0
+  - name: proxy
+    image: proxy:1.0
+`
+
+	actual, report, err := Visitor{InferAssociativeLists: true, ReadOnlyFields: ReadOnlyKeepDestAndWarn}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the comments are written where dest had them
+	assert.Equal(t, strings.TrimSpace(`
+kind: Deployment
+spec:
+  containers:
+  # readonly
+  - name: nginx
+    image: nginx:1.7
+  - # readonly
+    name: sidecar
+    image: sidecar:1.0
+  - name: proxy
+    image: proxy:1.1
+`), strings.TrimSpace(actual))
+	assert.Equal(t, []string{
+		`spec.containers[name=nginx] is marked readonly in dest, so the change by update to "{name: nginx, image: 'nginx:1.8'}" was not merged`,
+		`spec.containers[name=sidecar] is marked readonly in dest, so the change by update to "{name: sidecar, image: 'sidecar:1.1'}" was not merged`,
+	}, report.Warnings)
+
+	// the comments are kept, so the elements are still readonly when the
+	// output is merged again
+	again, report, err := Visitor{InferAssociativeLists: true, ReadOnlyFields: ReadOnlyKeepDestAndWarn}.
+		MergeStrings(actual, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(actual), strings.TrimSpace(again))
+	assert.Len(t, report.Warnings, 2)
+
+	// a comment after the first field marks the element too
+	local = strings.Replace(strings.Replace(local, "  - # readonly\n    name: sidecar\n", "  - name: sidecar # readonly\n", 1),
+		"  # readonly\n  - name: nginx\n", "  - name: nginx\n", 1)
+	actual, report, err = Visitor{InferAssociativeLists: true, ReadOnlyFields: ReadOnlyKeepDestAndWarn}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, actual, "  - name: sidecar # readonly\n    image: sidecar:1.0\n")
+	assert.Contains(t, actual, "image: nginx:1.8")
+	assert.Len(t, report.Warnings, 1)
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Reference is a field of a resource whose value is the name of another
+// resource in the same namespace.
+type Reference struct {
+	// Kind is the kind of the referencing resources.  Empty matches all kinds.
+	Kind string
+
+	// Path is the path to the referencing field, with fields separated by
+	// `.`.  The elements of the lists on the path are all searched, e.g.
+	// `spec.template.spec.volumes.configMap.name`.
+	Path string
+
+	// TargetKind is the kind of the referenced resources.
+	TargetKind string
+}
+
+// KubernetesReferences returns the References from Kubernetes workloads to
+// the ConfigMaps and Secrets they use.
+func KubernetesReferences() []Reference {
+	var refs []Reference
+	for _, p := range []string{"spec.template.spec", "spec.jobTemplate.spec.template.spec"} {
+		refs = append(refs,
+			Reference{Path: p + ".volumes.configMap.name", TargetKind: "ConfigMap"},
+			Reference{Path: p + ".containers.envFrom.configMapRef.name", TargetKind: "ConfigMap"},
+			Reference{Path: p + ".containers.env.valueFrom.configMapKeyRef.name", TargetKind: "ConfigMap"},
+			Reference{Path: p + ".volumes.secret.secretName", TargetKind: "Secret"},
+			Reference{Path: p + ".containers.envFrom.secretRef.name", TargetKind: "Secret"},
+			Reference{Path: p + ".containers.env.valueFrom.secretKeyRef.name", TargetKind: "Secret"},
+		)
+	}
+	return refs
+}
+
+// checkReferences records a warning in the Report of each output resource
+// which references a resource that was in dest but is not in the output,
+// e.g. because update deleted it, or whose content in the output differs
+// from dest.  destHashes are the referenceHashes of dest before the merge,
+// since the merge modifies dest.
+func (r Registry) checkReferences(destHashes map[string]string, output []*yaml.RNode, reports map[string]*Report) error {
+	if len(r.References) == 0 {
+		return nil
+	}
+	outputHashes, err := referenceHashes(output)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range output {
+		meta, err := node.GetMeta()
+		if err != nil {
+			return err
+		}
+		for _, ref := range r.References {
+			if ref.Kind != "" && ref.Kind != meta.Kind {
+				continue
+			}
+			for _, name := range referencedNames(node, strings.Split(ref.Path, ".")) {
+				key := referenceKey(ref.TargetKind, meta.Namespace, name)
+				before, found := destHashes[key]
+				after, kept := outputHashes[key]
+				var change string
+				switch {
+				case !found:
+					continue
+				case !kept:
+					change = "deleted"
+				case before != after:
+					change = "changed"
+				default:
+					continue
+				}
+				report := reports[ResourceKey(meta)]
+				if report == nil {
+					report = &Report{}
+					reports[ResourceKey(meta)] = report
+				}
+				report.Warnings = append(report.Warnings, fmt.Sprintf(
+					"%s references %s %s which the merge %s", ref.Path, ref.TargetKind, name, change))
+			}
+		}
+	}
+	return nil
+}
+
+// referenceHashes returns the Hash of each resource, by the key identifying
+// the resource for References.
+func referenceHashes(nodes []*yaml.RNode) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, node := range nodes {
+		meta, err := node.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := Hash(node)
+		if err != nil {
+			return nil, err
+		}
+		hashes[referenceKey(meta.Kind, meta.Namespace, meta.Name)] = hash
+	}
+	return hashes, nil
+}
+
+// referenceKey identifies a resource by its kind, namespace and name, since
+// References don't specify the apiVersion of the referenced resources.
+func referenceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// referencedNames returns the values of the fields at path in node, searching
+// all the elements of the lists on the path.
+func referencedNames(node *yaml.RNode, path []string) []string {
+	if yaml.IsMissingOrNull(node) {
+		return nil
+	}
+	switch node.YNode().Kind {
+	case yaml.SequenceNode:
+		var names []string
+		for _, e := range node.Content() {
+			names = append(names, referencedNames(yaml.NewRNode(e), path)...)
+		}
+		return names
+	case yaml.MappingNode:
+		if len(path) == 0 {
+			return nil
+		}
+		return referencedNames(mapFieldValue(node, path[0]), path[1:])
+	case yaml.ScalarNode:
+		if len(path) != 0 || node.YNode().Value == "" {
+			return nil
+		}
+		return []string{node.YNode().Value}
+	default:
+		return nil
+	}
+}
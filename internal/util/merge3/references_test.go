@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_References(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+`
+	update := `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2.0
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        envFrom:
+        - configMapRef:
+            name: config
+        - secretRef:
+            name: creds
+      volumes:
+      - name: config
+        configMap:
+          name: config
+`
+
+	r := Registry{
+		Default:    Kubernetes(),
+		References: KubernetesReferences(),
+	}
+	_, reports, err := r.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		"spec.template.spec.volumes.configMap.name references ConfigMap config which the merge deleted",
+		"spec.template.spec.containers.envFrom.configMapRef.name references ConfigMap config which the merge deleted",
+	}, reports["apps/v1/Deployment//app"].Warnings)
+	assert.Empty(t, reports["v1/Secret//creds"].Warnings)
+
+	// changes to referenced resources are warned about
+	changed := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: debug
+---
+` + update
+	_, reports, err = r.MergeStrings(local, origin, changed)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		"spec.template.spec.volumes.configMap.name references ConfigMap config which the merge changed",
+		"spec.template.spec.containers.envFrom.configMapRef.name references ConfigMap config which the merge changed",
+	}, reports["apps/v1/Deployment//app"].Warnings)
+
+	// references are only checked when configured
+	_, reports, err = Registry{Default: Kubernetes()}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, reports["apps/v1/Deployment//app"].Warnings)
+}
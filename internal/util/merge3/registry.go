@@ -0,0 +1,261 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// Registry merges multi-document streams of resources, selecting the Visitor
+// used to merge each resource by its kind.
+type Registry struct {
+	// Default is the Visitor used for kinds which are not in Kinds.
+	Default Visitor
+
+	// Kinds maps a resource kind to the Visitor used to merge it.
+	Kinds map[string]Visitor
+
+	// ConflictStrategies maps a resource kind to the ConflictStrategy used
+	// when its Visitor doesn't set one, e.g. so that Secrets keep the dest
+	// value while other kinds take the update.
+	ConflictStrategies map[string]ConflictStrategy
+
+	// Provenance if set annotates the merged resources which the merge
+	// changed with the merge time and the number of changed fields.
+	Provenance *Provenance
+
+	// References are the fields which reference other resources by name.
+	// A warning is recorded in the Report of each resource which references
+	// a resource the merge changed or deleted.
+	References []Reference
+
+	// CrossDocumentAliases controls how MergeStrings handles aliases which
+	// reference an anchor in another document of a stream.  Defaults to
+	// CrossDocumentAliasesError.
+	CrossDocumentAliases CrossDocumentAliasPolicy
+
+	// EmptyUpdate controls how Merge handles an update without any
+	// resources when origin has resources, e.g. because upstream removed
+	// everything.  Defaults to EmptyUpdateError, as deleting every resource
+	// of dest is rarely intended.
+	EmptyUpdate EmptyUpdatePolicy
+
+	// SkipIdenticalInputs if set to true makes Merge and MergeStrings return
+	// dest unchanged, without any Reports, if dest, original and update are
+	// identical.  MergeStrings compares the streams before parsing them.
+	SkipIdenticalInputs bool
+
+	// Fingerprints if set to true records the Fingerprint of the changes to
+	// each merged resource in its Report.
+	Fingerprints bool
+}
+
+// EmptyUpdatePolicy controls how an update without any resources is merged.
+type EmptyUpdatePolicy int
+
+const (
+	// EmptyUpdateError fails the merge.
+	EmptyUpdateError EmptyUpdatePolicy = iota
+
+	// EmptyUpdateKeepDest keeps the dest resources unchanged.
+	EmptyUpdateKeepDest
+
+	// EmptyUpdateDelete merges the update like any other, deleting the dest
+	// resources which are in origin.
+	EmptyUpdateDelete
+)
+
+// Visitor returns the Visitor used to merge resources of kind.
+func (r Registry) Visitor(kind string) Visitor {
+	v, found := r.Kinds[kind]
+	if !found {
+		v = r.Default
+	}
+	if s, found := r.ConflictStrategies[kind]; found && v.ConflictStrategy == 0 {
+		v.ConflictStrategy = s
+		v.kindStrategy = true
+	}
+	return v
+}
+
+// Merge merges the changes between the original and update resources into
+// the dest resources.  Resources are matched by their apiVersion, kind,
+// namespace and name.  The returned Reports are keyed by ResourceKey, and
+// include the merged resources and the resources with reference warnings.
+func (r Registry) Merge(dest, original, update []*yaml.RNode) ([]*yaml.RNode, map[string]*Report, error) {
+	if r.SkipIdenticalInputs && identicalResources(dest, original, update) {
+		return dest, map[string]*Report{}, nil
+	}
+	var ts tuples
+	for _, s := range []struct {
+		nodes []*yaml.RNode
+		index int
+	}{{dest, walk.DestIndex}, {original, walk.OriginIndex}, {update, walk.UpdatedIndex}} {
+		for i := range s.nodes {
+			if err := ts.add(s.nodes[i], s.index); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if len(update) == 0 && len(original) > 0 {
+		switch r.EmptyUpdate {
+		case EmptyUpdateError:
+			return nil, nil, &Error{
+				Kind:    ErrorKindEmptyUpdate,
+				Message: fmt.Sprintf("update contains no resources, which would delete the %d resources of origin", len(original)),
+			}
+		case EmptyUpdateKeepDest:
+			return dest, map[string]*Report{}, nil
+		}
+	}
+
+	var destHashes map[string]string
+	if len(r.References) > 0 {
+		// the merge modifies dest
+		var err error
+		if destHashes, err = referenceHashes(dest); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var output []*yaml.RNode
+	reports := map[string]*Report{}
+	for _, t := range ts {
+		d, o, u := t.nodes[walk.DestIndex], t.nodes[walk.OriginIndex], t.nodes[walk.UpdatedIndex]
+		switch {
+		case o == nil && u == nil && d != nil:
+			// added locally -- keep dest
+			output = append(output, d)
+		case o == nil && u != nil && d == nil:
+			// added in the update -- add update
+			output = append(output, u)
+		case o != nil && (u == nil || d == nil):
+			// deleted in the update or locally -- don't include the resource
+		default:
+			var before *yaml.RNode
+			if r.Provenance != nil {
+				// the merge modifies dest
+				before = d.Copy()
+			}
+			v := r.Visitor(t.meta.Kind)
+			classify := v.ClassifyChanges
+			if r.Fingerprints {
+				v.ClassifyChanges = true
+			}
+			node, report, err := v.Merge(d, o, u)
+			if err != nil {
+				return nil, nil, errors.WrapPrefixf(err, "%s", ResourceKey(t.meta))
+			}
+			if r.Fingerprints {
+				report.Fingerprint = fingerprint(report.Changes)
+				if !classify {
+					report.Changes = nil
+				}
+			}
+			if r.Provenance != nil {
+				if err := r.Provenance.annotate(before, node); err != nil {
+					return nil, nil, errors.WrapPrefixf(err, "%s", ResourceKey(t.meta))
+				}
+			}
+			reports[ResourceKey(t.meta)] = report
+			if node != nil {
+				output = append(output, node)
+			}
+		}
+	}
+	if err := r.checkReferences(destHashes, output, reports); err != nil {
+		return nil, nil, err
+	}
+	return output, reports, nil
+}
+
+// MergeStrings parses the multi-document dest, original and update streams
+// and merges them.
+func (r Registry) MergeStrings(dest, original, update string) (string, map[string]*Report, error) {
+	if r.SkipIdenticalInputs && dest == original && dest == update {
+		// parse one of the inputs, so that invalid inputs are still an error
+		if _, err := kio.FromBytes([]byte(dest)); err != nil {
+			return "", nil, err
+		}
+		return dest, map[string]*Report{}, nil
+	}
+	var sources [3][]*yaml.RNode
+	names := []string{"dest", "origin", "update"}
+	for i, s := range []string{dest, original, update} {
+		s, err := r.resolveCrossDocumentAliases(names[i], s)
+		if err != nil {
+			return "", nil, err
+		}
+		nodes, err := kio.FromBytes([]byte(s))
+		if err != nil {
+			return "", nil, err
+		}
+		sources[i] = nodes
+	}
+	result, reports, err := r.Merge(sources[0], sources[1], sources[2])
+	if err != nil {
+		return "", nil, err
+	}
+	s, err := kio.StringAll(result)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, reports, nil
+}
+
+// ResourceKey returns the key identifying a resource in a multi-document
+// merge, e.g. `apps/v1/Deployment/default/app`.
+func ResourceKey(meta yaml.ResourceMeta) string {
+	return fmt.Sprintf("%s/%s/%s/%s", meta.APIVersion, meta.Kind, meta.Namespace, meta.Name)
+}
+
+// tuple contains the dest, origin and update versions of a resource, indexed
+// like walk.Sources.
+type tuple struct {
+	meta  yaml.ResourceMeta
+	nodes [3]*yaml.RNode
+}
+
+// tuples combines the resources with the same apiVersion, kind, namespace
+// and name, in the order they are first seen.
+type tuples []*tuple
+
+func (ts *tuples) add(node *yaml.RNode, index int) error {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return err
+	}
+	key := ResourceKey(meta)
+	for _, t := range *ts {
+		if ResourceKey(t.meta) != key {
+			continue
+		}
+		if t.nodes[index] != nil {
+			return errors.Errorf("resource %s is specified more than once", key)
+		}
+		t.nodes[index] = node
+		return nil
+	}
+	t := &tuple{meta: meta}
+	t.nodes[index] = node
+	*ts = append(*ts, t)
+	return nil
+}
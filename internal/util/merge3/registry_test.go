@@ -0,0 +1,272 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_MergeStrings(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: origin
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: deleted
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: local
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: deleted
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local
+`
+	expected := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added
+`
+
+	actual, reports, err := Registry{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+	if assert.Contains(t, reports, "v1/ConfigMap//a") {
+		assert.Len(t, reports["v1/ConfigMap//a"].Conflicts, 1)
+	}
+}
+
+func TestRegistry_ConflictStrategies(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: origin
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+data:
+  value: origin
+`
+	update := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+data:
+  value: update
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: local
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+data:
+  value: local
+`
+	expected := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: update
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+data:
+  value: local
+`
+
+	r := Registry{
+		ConflictStrategies: map[string]ConflictStrategy{
+			"ConfigMap": TakeUpdate,
+			"Secret":    TakeDest,
+		},
+	}
+	actual, reports, err := r.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+	// conflicts are reported regardless of how they were resolved
+	assert.Len(t, reports["v1/ConfigMap//a"].Conflicts, 1)
+	assert.Len(t, reports["v1/Secret//a"].Conflicts, 1)
+
+	// a strategy set on the kind's Visitor takes precedence
+	r.Kinds = map[string]Visitor{"Secret": {ConflictStrategy: TakeUpdate}}
+	assert.Equal(t, TakeUpdate, r.Visitor("Secret").ConflictStrategy)
+	assert.Equal(t, TakeDest, Registry{ConflictStrategies: r.ConflictStrategies}.Visitor("Secret").ConflictStrategy)
+}
+
+func TestRegistry_duplicateResource(t *testing.T) {
+	_, _, err := Registry{}.MergeStrings(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+`, "", "")
+	assert.EqualError(t, err, "resource v1/ConfigMap//a is specified more than once")
+}
+
+func TestRegistry_EmptyUpdate(t *testing.T) {
+	origin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: origin
+`
+	local := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  value: local
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+`
+
+	var testCases = []struct {
+		description string
+		policy      EmptyUpdatePolicy
+		origin      string
+		expected    string
+		err         *Error
+	}{
+		{
+			description: `empty update is an error by default`,
+			origin:      origin,
+			err: &Error{
+				Kind:    ErrorKindEmptyUpdate,
+				Message: "update contains no resources, which would delete the 1 resources of origin",
+			},
+		},
+		{
+			description: `empty update keeps dest`,
+			policy:      EmptyUpdateKeepDest,
+			origin:      origin,
+			expected:    local,
+		},
+		{
+			description: `empty update deletes the resources of origin`,
+			policy:      EmptyUpdateDelete,
+			origin:      origin,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+`,
+		},
+		{
+			description: `empty update and origin keep dest`,
+			expected:    local,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Registry{EmptyUpdate: tc.policy}.MergeStrings(local, tc.origin, "")
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
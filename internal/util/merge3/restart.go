@@ -0,0 +1,68 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// templateHash returns the Hash of the `spec.template` of node, without the
+// RestartAnnotation, or the empty string if node doesn't have a template.
+// Empty metadata is ignored, so that the hash is the same before and after
+// the annotation is set.
+func (m Visitor) templateHash(node *yaml.RNode) (string, error) {
+	if yaml.IsMissingOrNull(node) {
+		return "", nil
+	}
+	template, err := node.Pipe(yaml.Lookup("spec", "template"))
+	if err != nil || template == nil {
+		return "", err
+	}
+	template = template.Copy()
+	if _, err := template.Pipe(yaml.Lookup(yaml.MetadataField, yaml.AnnotationsField),
+		yaml.Clear(m.RestartAnnotation)); err != nil {
+		return "", err
+	}
+	// setting the annotation may have created empty parents
+	for _, path := range [][]string{{yaml.MetadataField, yaml.AnnotationsField}, {yaml.MetadataField}} {
+		node, err := template.Pipe(yaml.Lookup(path...))
+		if err != nil {
+			return "", err
+		}
+		if node != nil && len(node.Content()) == 0 {
+			parent, err := template.Pipe(yaml.Lookup(path[:len(path)-1]...))
+			if err != nil {
+				return "", err
+			}
+			if _, err := parent.Pipe(yaml.Clear(path[len(path)-1])); err != nil {
+				return "", err
+			}
+		}
+	}
+	return Hash(template)
+}
+
+// triggerRestart sets the RestartAnnotation on the `spec.template` of the
+// merged result to the hash of the template, if the merge changed the
+// template from before, the hash of the dest template.
+func (m Visitor) triggerRestart(result *yaml.RNode, before string) error {
+	after, err := m.templateHash(result)
+	if err != nil || after == "" || after == before {
+		return err
+	}
+	return result.PipeE(
+		yaml.LookupCreate(yaml.MappingNode, "spec", "template", yaml.MetadataField, yaml.AnnotationsField),
+		yaml.SetField(m.RestartAnnotation, yaml.NewStringRNode(after)))
+}
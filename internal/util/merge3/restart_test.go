@@ -0,0 +1,191 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_RestartAnnotation(t *testing.T) {
+	const annotation = "example.com/restarted-by"
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		restart     bool
+	}{
+		{
+			description: `template change sets the annotation`,
+			origin: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			update: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8`,
+			local: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			restart: true,
+		},
+		{
+			description: `template change updates the annotation`,
+			origin: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			update: `
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8`,
+			local: `
+kind: Deployment
+spec:
+  template:
+    metadata:
+      annotations:
+        example.com/restarted-by: old
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			restart: true,
+		},
+		{
+			description: `change outside the template doesn't set the annotation`,
+			origin: `
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			update: `
+kind: Deployment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+			local: `
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    metadata:
+      annotations:
+        example.com/restarted-by: old
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7`,
+		},
+		{
+			description: `no template doesn't set the annotation`,
+			origin: `
+kind: ConfigMap
+data:
+  a: b`,
+			update: `
+kind: ConfigMap
+data:
+  a: c`,
+			local: `
+kind: ConfigMap
+data:
+  a: b`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			before, err := yaml.Parse(tc.local)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual, _, err := Visitor{RestartAnnotation: annotation}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			result, err := yaml.Parse(actual)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			path := []string{"spec", "template", "metadata", "annotations", annotation}
+			value, err := result.Pipe(yaml.Lookup(path...))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if !tc.restart {
+				expected, err := before.Pipe(yaml.Lookup(path...))
+				if !assert.NoError(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, expected.MustString(), value.MustString())
+				return
+			}
+
+			// the annotation is the hash of the merged template without the
+			// annotation, which is the update template
+			if !assert.NotNil(t, value) {
+				t.FailNow()
+			}
+			template, err := yaml.MustParse(tc.update).Pipe(yaml.Lookup("spec", "template"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			hash, err := Hash(template)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, hash, strings.TrimSpace(value.MustString()))
+		})
+	}
+}
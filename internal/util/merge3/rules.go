@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+// Rules which apply to every matching path are identified by the name of the
+// Visitor field configuring them.  Rules configured per path are identified
+// by the field and the configured path, e.g. `SortListsBy: spec.rules`, and
+// inline hints by their comment, e.g. `# merge-key: name`.
+const (
+	ruleReplay        = "Replay"
+	ruleMaxMergeDepth = "MaxMergeDepth"
+	ruleStatus        = "Status"
+	ruleServerFields  = "ServerFields"
+)
+
+// matchRule records that rule matched the field at path.
+func (m Visitor) matchRule(rule string, path []string) {
+	if !m.RecordRuleMatches || m.report == nil {
+		return
+	}
+	m.report.RuleMatches[rule] = append(m.report.RuleMatches[rule], pathString(path))
+}
+
+// pathRule returns the name of a rule configured for a path by field.
+func pathRule(field string, path []string) string {
+	return field + ": " + pathString(path)
+}
+
+// rules returns the names of the rules declared by the hints.
+func (h hints) rules() []string {
+	var rules []string
+	if h.mergeKey != "" {
+		rules = append(rules, "# "+mergeKeyHint+" "+h.mergeKey)
+	}
+	if h.atomic {
+		rules = append(rules, "# "+atomicHint)
+	}
+	return rules
+}
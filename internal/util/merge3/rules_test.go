@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_RecordRuleMatches(t *testing.T) {
+	origin := `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: foo
+  resourceVersion: "1"
+spec:
+  args: [a]
+  rules:
+  - name: b
+  - name: a
+  # merge-key: id
+  items:
+  - id: x
+  # atomic
+  selector:
+    app: foo
+status:
+  ready: true
+`
+	update := `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: foo
+  resourceVersion: "2"
+spec:
+  args: [a, b]
+  rules:
+  - name: b
+  - name: a
+  - name: c
+  # merge-key: id
+  items:
+  - id: x
+  - id: y
+  # atomic
+  selector:
+    app: bar
+status:
+  ready: false
+`
+	local := `
+apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: foo
+  resourceVersion: "3"
+spec:
+  args: [a]
+  rules:
+  - name: b
+  - name: a
+  # merge-key: id
+  items:
+  - id: x
+  # atomic
+  selector:
+    app: foo
+status:
+  ready: true
+`
+	v := Kubernetes()
+	v.RecordRuleMatches = true
+	v.OrderedSetLists = []string{"spec.args", "spec.missing"}
+	v.SortListsBy = map[string]string{"spec.rules": "name"}
+	_, report, err := v.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string][]string{
+		"OrderedSetLists: spec.args": {"spec.args"},
+		"SortListsBy: spec.rules":    {"spec.rules"},
+		"# merge-key: id":            {"spec.items"},
+		"# atomic":                   {"spec.selector"},
+		"ServerFields":               {"metadata.resourceVersion"},
+		"Status":                     {"status"},
+	}, report.RuleMatches)
+
+	// rule matches are only recorded when requested
+	v.RecordRuleMatches = false
+	_, report, err = v.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.RuleMatches)
+}
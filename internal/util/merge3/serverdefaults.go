@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// podDefaults are the fields of a pod spec which the Kubernetes API server
+// defaults, relative to the pod spec.
+var podDefaults = map[string]string{
+	"restartPolicy":                              "Always",
+	"dnsPolicy":                                  "ClusterFirst",
+	"schedulerName":                              "default-scheduler",
+	"terminationGracePeriodSeconds":              "30",
+	"containers[*].terminationMessagePath":       "/dev/termination-log",
+	"containers[*].terminationMessagePolicy":     "File",
+	"containers[*].ports[*].protocol":            "TCP",
+	"initContainers[*].terminationMessagePath":   "/dev/termination-log",
+	"initContainers[*].terminationMessagePolicy": "File",
+}
+
+// KubernetesServerDefaults returns the fields of common Kubernetes kinds
+// which the API server sets to a default value, for use as ServerDefaults.
+func KubernetesServerDefaults() map[string]map[string]string {
+	withPodDefaults := func(prefix string, defaults map[string]string) map[string]string {
+		for path, value := range podDefaults {
+			defaults[prefix+path] = value
+		}
+		return defaults
+	}
+	return map[string]map[string]string{
+		"Deployment": withPodDefaults("spec.template.spec.", map[string]string{
+			"spec.progressDeadlineSeconds": "600",
+			"spec.revisionHistoryLimit":    "10",
+			"spec.strategy.type":           "RollingUpdate",
+		}),
+		"StatefulSet": withPodDefaults("spec.template.spec.", map[string]string{
+			"spec.podManagementPolicy":  "OrderedReady",
+			"spec.revisionHistoryLimit": "10",
+			"spec.updateStrategy.type":  "RollingUpdate",
+		}),
+		"DaemonSet": withPodDefaults("spec.template.spec.", map[string]string{
+			"spec.revisionHistoryLimit": "10",
+			"spec.updateStrategy.type":  "RollingUpdate",
+		}),
+		"Job": withPodDefaults("spec.template.spec.", map[string]string{
+			"spec.backoffLimit": "6",
+		}),
+		"Pod": withPodDefaults("spec.", map[string]string{}),
+		"Service": {
+			"spec.sessionAffinity":   "None",
+			"spec.type":              "ClusterIP",
+			"spec.ports[*].protocol": "TCP",
+		},
+	}
+}
+
+// serverDefault returns the merged value of a field and the reason it was
+// chosen, and true if dest has the server default value for the field and
+// origin doesn't have the field, in which case dest is treated as if it
+// didn't have the field either.
+func (m Visitor) serverDefault(nodes walk.Sources, path []string) (*yaml.RNode, string, bool) {
+	defaults, found := m.ServerDefaults[m.kind]
+	if !found || !yaml.IsMissingOrNull(nodes.Origin()) || yaml.IsMissingOrNull(nodes.Dest()) ||
+		nodes.Dest().YNode().Kind != yaml.ScalarNode {
+		return nil, "", false
+	}
+	p := pathString(path)
+	value, found := defaults[p]
+	if !found {
+		p = pathPattern(p)
+		if value, found = defaults[p]; !found {
+			return nil, "", false
+		}
+	}
+	if nodes.Dest().YNode().Value != value {
+		return nil, "", false
+	}
+	m.matchRule("ServerDefaults: "+m.kind+" "+p, path)
+	if yaml.IsMissingOrNull(nodes.Updated()) {
+		return nodes.Dest(), "kept dest because it is the server default", true
+	}
+	return nodes.Updated(), "took update because dest is the server default", true
+}
+
+// resourceKind returns the kind of the first of nodes which has one.
+func resourceKind(nodes ...*yaml.RNode) string {
+	for _, n := range nodes {
+		if yaml.IsMissingOrNull(n) {
+			continue
+		}
+		if meta, err := n.GetMeta(); err == nil && meta.Kind != "" {
+			return meta.Kind
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ServerDefaults(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		defaults    map[string]map[string]string
+		conflicts   int
+	}{
+		{
+			description: `defaulted fields don't conflict`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      restartPolicy: OnFailure
+      containers:
+      - name: app
+        image: app:1.0
+        terminationMessagePolicy: FallbackToLogsOnError`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      restartPolicy: Always
+      dnsPolicy: ClusterFirst
+      containers:
+      - name: app
+        image: app:1.0
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      restartPolicy: OnFailure
+      dnsPolicy: ClusterFirst
+      containers:
+      - name: app
+        image: app:1.0
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: FallbackToLogsOnError`,
+			defaults: KubernetesServerDefaults(),
+		},
+		{
+			description: `defaulted fields conflict without the defaults`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers: []`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      restartPolicy: OnFailure
+      containers: []`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      restartPolicy: Always
+      containers: []`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      restartPolicy: OnFailure
+      containers: []`,
+			conflicts: 1,
+		},
+		{
+			description: `non-default values in dest still conflict`,
+			origin: `
+apiVersion: v1
+kind: Service
+spec:
+  selector:
+    app: a`,
+			update: `
+apiVersion: v1
+kind: Service
+spec:
+  type: LoadBalancer
+  selector:
+    app: a`,
+			local: `
+apiVersion: v1
+kind: Service
+spec:
+  type: NodePort
+  selector:
+    app: a`,
+			expected: `
+apiVersion: v1
+kind: Service
+spec:
+  type: LoadBalancer
+  selector:
+    app: a`,
+			defaults:  KubernetesServerDefaults(),
+			conflicts: 1,
+		},
+		{
+			description: `defaults only apply to their kind`,
+			origin: `
+apiVersion: example.com/v1
+kind: Foo
+spec: {}`,
+			update: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  type: Other`,
+			local: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  type: ClusterIP`,
+			expected: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  type: Other`,
+			defaults:  KubernetesServerDefaults(),
+			conflicts: 1,
+		},
+		{
+			description: `custom defaults`,
+			origin: `
+apiVersion: example.com/v1
+kind: Foo
+spec: {}`,
+			update: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  mode: fast`,
+			local: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  mode: auto`,
+			expected: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  mode: fast`,
+			defaults: map[string]map[string]string{"Foo": {"spec.mode": "auto"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{InferAssociativeLists: true, ServerDefaults: tc.defaults}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.Len(t, report.Conflicts, tc.conflicts)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// serverFields are the metadata fields set by the Kubernetes API server.
+// They are present when dest was read back from a cluster.
+var serverFields = []string{"generation", "resourceVersion"}
+
+// ServerFieldPolicy controls how the server-managed metadata fields
+// (`metadata.generation` and `metadata.resourceVersion`) are merged.
+type ServerFieldPolicy int
+
+const (
+	// ServerFieldsMerge merges the fields like any other field.
+	ServerFieldsMerge ServerFieldPolicy = iota
+
+	// ServerFieldsKeepDest keeps the fields from dest, ignoring origin and
+	// update.
+	ServerFieldsKeepDest
+
+	// ServerFieldsDrop removes the fields from the merged resource.
+	ServerFieldsDrop
+)
+
+// serverField returns the merged value of a server-managed field, and true if
+// path is a server-managed field and it is not merged like other fields.
+func (m Visitor) serverField(nodes walk.Sources, path []string) (*yaml.RNode, bool) {
+	if !isServerField(path) {
+		return nil, false
+	}
+	switch m.ServerFields {
+	case ServerFieldsKeepDest:
+		return nodes.Dest(), true
+	case ServerFieldsDrop:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// isServerField returns true if path is a server-managed metadata field.
+func isServerField(path []string) bool {
+	if len(path) != 2 || path[0] != "metadata" {
+		return false
+	}
+	for _, f := range serverFields {
+		if path[1] == f {
+			return true
+		}
+	}
+	return false
+}
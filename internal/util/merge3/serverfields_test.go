@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ServerFields(t *testing.T) {
+	origin := `
+kind: Foo
+metadata:
+  name: foo
+  generation: 1
+  resourceVersion: "100"
+spec:
+  replicas: 1
+`
+	update := `
+kind: Foo
+metadata:
+  name: foo
+  generation: 2
+  resourceVersion: "200"
+spec:
+  replicas: 2
+`
+	// dest was read back from a cluster
+	local := `
+kind: Foo
+metadata:
+  name: foo
+  generation: 7
+  resourceVersion: "12345"
+spec:
+  replicas: 1
+`
+
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		expected    string
+		conflicts   []string
+	}{
+		{
+			description: `merge server fields`,
+			visitor:     Visitor{},
+			expected: `
+kind: Foo
+metadata:
+  name: foo
+  generation: 2
+  resourceVersion: "200"
+spec:
+  replicas: 2
+`,
+			conflicts: []string{"metadata.generation", "metadata.resourceVersion"},
+		},
+		{
+			description: `keep dest server fields`,
+			visitor:     Visitor{ServerFields: ServerFieldsKeepDest},
+			expected: `
+kind: Foo
+metadata:
+  name: foo
+  generation: 7
+  resourceVersion: "12345"
+spec:
+  replicas: 2
+`,
+		},
+		{
+			description: `drop server fields`,
+			visitor:     Visitor{ServerFields: ServerFieldsDrop},
+			expected: `
+kind: Foo
+metadata:
+  name: foo
+spec:
+  replicas: 2
+`,
+		},
+		{
+			description: `kubernetes preset keeps dest server fields`,
+			visitor:     Kubernetes(),
+			expected: `
+kind: Foo
+metadata:
+  name: foo
+  generation: 7
+  resourceVersion: "12345"
+spec:
+  replicas: 2
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			var conflicts []string
+			for _, c := range report.Conflicts {
+				conflicts = append(conflicts, c.Path)
+			}
+			assert.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+}
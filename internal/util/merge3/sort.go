@@ -0,0 +1,139 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// sortElements sorts the elements of the merged associative list at path
+// by the field configured for it in SortListsBy, and then by the field
+// configured in SecondarySortBy, or by the merge keys if the list is in
+// SortListsByKey.
+func (m Visitor) sortElements(list *yaml.RNode, path []string, keys []string) {
+	var fields []string
+	if field, found := m.SortListsBy[pathString(path)]; found {
+		m.matchRule(pathRule("SortListsBy", path), path)
+		fields = append(fields, field)
+	}
+	if field, found := m.SecondarySortBy[pathString(path)]; found {
+		m.matchRule(pathRule("SecondarySortBy", path), path)
+		fields = append(fields, field)
+	} else if containsPath(m.SortListsByKey, path) {
+		m.matchRule(pathRule("SortListsByKey", path), path)
+		fields = append(fields, keys...)
+	}
+	if len(fields) == 0 {
+		return
+	}
+	elements := list.YNode().Content
+	sort.SliceStable(elements, func(i, j int) bool {
+		for _, field := range fields {
+			if lessByField(elements[i], elements[j], field) {
+				return true
+			}
+			if lessByField(elements[j], elements[i], field) {
+				return false
+			}
+		}
+		return false
+	})
+}
+
+// lessByField returns true if the value of field on a sorts before the value
+// of field on b.  Numbers sort before other values and are compared
+// numerically, with NaN after every other number, while other values are
+// compared lexically.  Only values which are ints or floats are numbers, so
+// that e.g. quoted numbers or a key named `inf` are compared lexically.
+// Elements missing the field sort last.
+func lessByField(a, b *yaml.Node, field string) bool {
+	av, aFound := fieldNode(a, field)
+	bv, bFound := fieldNode(b, field)
+	if !aFound || !bFound {
+		return aFound && !bFound
+	}
+	an, aNumber := sortNumber(av)
+	bn, bNumber := sortNumber(bv)
+	switch {
+	case aNumber && bNumber:
+		return an.less(bn)
+	case aNumber || bNumber:
+		// order by type first, so that the order is consistent for lists
+		// mixing numbers and other values
+		return aNumber
+	default:
+		return av.Value < bv.Value
+	}
+}
+
+// numericValue is the value of an int or float field, for sorting.
+type numericValue struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+// sortNumber returns the value of node and true if node is an int or float.
+func sortNumber(node *yaml.Node) (numericValue, bool) {
+	switch node.ShortTag() {
+	case yaml.NodeTagInt:
+		i, err := strconv.ParseInt(node.Value, 0, 64)
+		return numericValue{i: i, f: float64(i), isInt: true}, err == nil
+	case yaml.NodeTagFloat:
+		f, ok := parseFloat(node.Value)
+		return numericValue{f: f}, ok
+	default:
+		return numericValue{}, false
+	}
+}
+
+// less returns true if n sorts before o.  Ints are compared exactly, and NaN
+// sorts after every other number so that the order is strict.
+func (n numericValue) less(o numericValue) bool {
+	switch {
+	case n.isInt && o.isInt:
+		return n.i < o.i
+	case math.IsNaN(n.f):
+		return false
+	case math.IsNaN(o.f):
+		return true
+	default:
+		return n.f < o.f
+	}
+}
+
+func fieldValue(node *yaml.Node, field string) (string, bool) {
+	if f, found := fieldNode(node, field); found {
+		return f.Value, true
+	}
+	return "", false
+}
+
+// fieldNode returns the scalar value node of field on node, or node itself
+// if field is empty, and true if it is a scalar.
+func fieldNode(node *yaml.Node, field string) (*yaml.Node, bool) {
+	if field == "" {
+		return node, node.Kind == yaml.ScalarNode
+	}
+	f := yaml.NewRNode(node).Field(field)
+	if f == nil || yaml.IsMissingOrNull(f.Value) || f.Value.YNode().Kind != yaml.ScalarNode {
+		return nil, false
+	}
+	return f.Value.YNode(), true
+}
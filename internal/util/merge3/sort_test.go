@@ -0,0 +1,448 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_SortListsBy(t *testing.T) {
+	origin := `
+kind: Policy
+spec:
+  rules:
+  - name: deny-all
+    priority: 100
+  - name: allow-dns
+    priority: 10
+`
+	update := `
+kind: Policy
+spec:
+  rules:
+  - name: deny-all
+    priority: 100
+  - name: allow-dns
+    priority: 10
+  - name: allow-metrics
+    priority: 20
+  - name: allow-health
+    priority: 5
+`
+	local := `
+kind: Policy
+spec:
+  rules:
+  - name: allow-dns
+    priority: 10
+    action: allow
+  - name: allow-local
+    priority: 50
+  - name: deny-all
+    priority: 100
+`
+
+	var testCases = []struct {
+		description string
+		sortListsBy map[string]string
+		expected    string
+	}{
+		{
+			description: `added elements are appended without sorting`,
+			expected: `
+kind: Policy
+spec:
+  rules:
+  - name: allow-dns
+    priority: 10
+    action: allow
+  - name: allow-local
+    priority: 50
+  - name: deny-all
+    priority: 100
+  - name: allow-metrics
+    priority: 20
+  - name: allow-health
+    priority: 5
+`,
+		},
+		{
+			description: `added elements are inserted in sorted order`,
+			sortListsBy: map[string]string{"spec.rules": "priority"},
+			expected: `
+kind: Policy
+spec:
+  rules:
+  - name: allow-health
+    priority: 5
+  - name: allow-dns
+    priority: 10
+    action: allow
+  - name: allow-metrics
+    priority: 20
+  - name: allow-local
+    priority: 50
+  - name: deny-all
+    priority: 100
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InferAssociativeLists: true, SortListsBy: tc.sortListsBy}.
+				MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_SortListsBy_mixed(t *testing.T) {
+	origin := `
+kind: Policy
+spec:
+  rules:
+  - name: a
+    priority: 10
+`
+	update := `
+kind: Policy
+spec:
+  rules:
+  - name: a
+    priority: 10
+  - name: b
+    priority: 9a
+  - name: c
+    priority: 9
+  - name: d
+    priority: high
+  - name: e
+    priority: 100
+`
+	expected := `
+kind: Policy
+spec:
+  rules:
+  - name: c
+    priority: 9
+  - name: a
+    priority: 10
+  - name: e
+    priority: 100
+  - name: b
+    priority: 9a
+  - name: d
+    priority: high
+`
+
+	// numbers sort before other values, so that the order is consistent
+	// whichever elements are compared
+	actual, _, err := Visitor{InferAssociativeLists: true, SortListsBy: map[string]string{"spec.rules": "priority"}}.
+		MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+}
+
+func TestVisitor_SortListsBy_nonNumbers(t *testing.T) {
+	origin := `
+kind: Policy
+spec:
+  rules:
+  - name: a
+    priority: 10
+`
+	update := `
+kind: Policy
+spec:
+  rules:
+  - name: a
+    priority: 10
+  - name: b
+    priority: .nan
+  - name: c
+    priority: inf
+  - name: d
+    priority: .inf
+  - name: e
+    priority: "1"
+  - name: f
+    priority: 2
+  - name: g
+    priority: nan
+`
+	expected := `
+kind: Policy
+spec:
+  rules:
+  - name: f
+    priority: 2
+  - name: a
+    priority: 10
+  - name: d
+    priority: .inf
+  - name: b
+    priority: .nan
+  - name: e
+    priority: "1"
+  - name: c
+    priority: inf
+  - name: g
+    priority: nan
+`
+
+	// only ints and floats are numbers, and NaN sorts after the other
+	// numbers
+	actual, _, err := Visitor{InferAssociativeLists: true, SortListsBy: map[string]string{"spec.rules": "priority"}}.
+		MergeStrings(origin, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+}
+
+func TestVisitor_SortListsByKey(t *testing.T) {
+	var testCases = []struct {
+		description     string
+		origin          string
+		update          string
+		local           string
+		expected        string
+		sortListsBy     map[string]string
+		secondarySortBy map[string]string
+		sortListsByKey  []string
+	}{
+		{
+			description: `elements are sorted by the merge key`,
+			origin: `
+spec:
+  backends:
+  - name: b
+    address: b:1`,
+			update: `
+spec:
+  backends:
+  - name: c
+    address: c:1
+  - name: b
+    address: b:2`,
+			local: `
+spec:
+  backends:
+  - name: b
+    address: b:1
+  - name: a
+    address: a:1`,
+			expected: `
+spec:
+  backends:
+  - name: a
+    address: a:1
+  - name: b
+    address: b:2
+  - address: c:1
+    name: c`,
+			sortListsByKey: []string{"spec.backends"},
+		},
+		{
+			description: `the order doesn't depend on the order of the sources`,
+			origin: `
+spec:
+  backends:
+  - name: b
+    address: b:1`,
+			update: `
+spec:
+  backends:
+  - name: b
+    address: b:2
+  - name: c
+    address: c:1`,
+			local: `
+spec:
+  backends:
+  - name: a
+    address: a:1
+  - name: b
+    address: b:1`,
+			expected: `
+spec:
+  backends:
+  - name: a
+    address: a:1
+  - name: b
+    address: b:2
+  - address: c:1
+    name: c`,
+			sortListsByKey: []string{"spec.backends"},
+		},
+		{
+			description: `SortListsBy takes precedence`,
+			origin: `
+spec:
+  rules:
+  - name: b
+    priority: 10`,
+			update: `
+spec:
+  rules:
+  - name: b
+    priority: 10
+  - name: c
+    priority: 5`,
+			local: `
+spec:
+  rules:
+  - name: b
+    priority: 10
+  - name: a
+    priority: 10`,
+			expected: `
+spec:
+  rules:
+  - name: c
+    priority: 5
+  - name: a
+    priority: 10
+  - name: b
+    priority: 10`,
+			sortListsBy:    map[string]string{"spec.rules": "priority"},
+			sortListsByKey: []string{"spec.rules"},
+		},
+		{
+			description: `configured secondary field`,
+			origin: `
+spec:
+  rules:
+  - name: b
+    host: y`,
+			update: `
+spec:
+  rules:
+  - name: b
+    host: y
+  - name: c
+    host: x`,
+			local: `
+spec:
+  rules:
+  - name: b
+    host: y
+  - name: a
+    host: z`,
+			expected: `
+spec:
+  rules:
+  - host: x
+    name: c
+  - name: b
+    host: y
+  - name: a
+    host: z`,
+			secondarySortBy: map[string]string{"spec.rules": "host"},
+			sortListsByKey:  []string{"spec.rules"},
+		},
+		{
+			description: `lists which aren't configured keep their order`,
+			origin: `
+spec:
+  containers:
+  - name: b
+    image: b:1`,
+			update: `
+spec:
+  containers:
+  - name: c
+    image: c:1
+  - name: b
+    image: b:2`,
+			local: `
+spec:
+  containers:
+  - name: b
+    image: b:1
+  - name: a
+    image: a:1`,
+			expected: `
+spec:
+  containers:
+  - name: b
+    image: b:2
+  - name: a
+    image: a:1
+  - image: c:1
+    name: c`,
+			sortListsByKey: []string{"spec.backends"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{
+				InferAssociativeLists: true,
+				SortListsByKey:        tc.sortListsByKey,
+				SortListsBy:           tc.sortListsBy,
+				SecondarySortBy:       tc.secondarySortBy,
+			}.MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_SortListsByKey_primitive(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  finalizers: [b]
+`
+	update := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  finalizers: [c, b]
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  finalizers: [b, a]
+`
+
+	actual, _, err := Visitor{SortListsByKey: []string{"metadata.finalizers"}}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  finalizers: [a, b, c]
+`), strings.TrimSpace(actual))
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// StatusPolicy controls how the `status` field of a resource is merged.
+// Kubernetes status is managed by the server, and usually shouldn't be merged
+// from upstream package content.
+type StatusPolicy int
+
+const (
+	// StatusMerge merges status like any other field.
+	StatusMerge StatusPolicy = iota
+
+	// StatusKeepDest keeps the status from dest, ignoring origin and update.
+	StatusKeepDest
+
+	// StatusDrop removes status from the merged resource.
+	StatusDrop
+)
+
+// status returns the merged value of the status field, and true if path is
+// the status field and it is not merged like other fields.
+func (m Visitor) status(nodes walk.Sources, path []string) (*yaml.RNode, bool) {
+	if len(path) != 1 || path[0] != "status" {
+		return nil, false
+	}
+	switch m.Status {
+	case StatusKeepDest:
+		return nodes.Dest(), true
+	case StatusDrop:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_Status(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+status:
+  ready: 1
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+status:
+  ready: 2
+  phase: Running
+`
+	local := `
+kind: Foo
+spec:
+  replicas: 1
+status:
+  ready: 1
+  observed: 3
+`
+
+	var testCases = []struct {
+		description string
+		visitor     Visitor
+		expected    string
+	}{
+		{
+			description: `merge status`,
+			visitor:     Visitor{Status: StatusMerge},
+			expected: `
+kind: Foo
+spec:
+  replicas: 2
+status:
+  ready: 2
+  observed: 3
+  phase: Running
+`,
+		},
+		{
+			description: `keep dest status`,
+			visitor:     Visitor{Status: StatusKeepDest},
+			expected: `
+kind: Foo
+spec:
+  replicas: 2
+status:
+  ready: 1
+  observed: 3
+`,
+		},
+		{
+			description: `drop status`,
+			visitor:     Visitor{Status: StatusDrop},
+			expected: `
+kind: Foo
+spec:
+  replicas: 2
+`,
+		},
+		{
+			description: `kubernetes preset keeps dest status`,
+			visitor:     Kubernetes(),
+			expected: `
+kind: Foo
+spec:
+  replicas: 2
+status:
+  ready: 1
+  observed: 3
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := tc.visitor.MergeStrings(local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_Status_nested(t *testing.T) {
+	// only the top-level status field is covered by the status policy
+	actual, _, err := Visitor{Status: StatusDrop}.MergeStrings(
+		"kind: Foo\nspec:\n  status: a\n",
+		"kind: Foo\nspec:\n  status: a\n",
+		"kind: Foo\nspec:\n  status: b\n")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "kind: Foo\nspec:\n  status: b", strings.TrimSpace(actual))
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+)
+
+// checkTopLevelKeys returns an error if a source has a top-level key which
+// isn't a field of the resource schema.  Resources without a schema aren't
+// checked.
+func (l walker) checkTopLevelKeys() error {
+	if !l.visitor.StrictTopLevelKeys || len(l.path) != 0 || l.schema == nil {
+		return nil
+	}
+	for _, key := range l.fieldNames() {
+		if l.schema.Field(key) == nil {
+			return &Error{
+				Path:    pathString([]string{key}),
+				Kind:    ErrorKindUnknownField,
+				Message: fmt.Sprintf("%s is not a field of the resource schema", key),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_StrictTopLevelKeys(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		strict      bool
+		err         string
+	}{
+		{
+			description: `unknown top-level key in dest is an error`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 2`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1
+specs:
+  paused: true`,
+			strict: true,
+			err:    "specs is not a field of the resource schema",
+		},
+		{
+			description: `unknown top-level key in update is an error`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 2
+extra: a`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1`,
+			strict: true,
+			err:    "extra is not a field of the resource schema",
+		},
+		{
+			description: `unknown top-level key is merged without the option`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 2`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 1
+specs:
+  paused: true`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 2
+specs:
+  paused: true`,
+		},
+		{
+			description: `known top-level keys are merged`,
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+status:
+  replicas: 1`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2
+status:
+  replicas: 1`,
+			strict: true,
+		},
+		{
+			description: `resources without a schema aren't checked`,
+			origin: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  a: 1`,
+			update: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  a: 2`,
+			local: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  a: 1
+extra: b`,
+			expected: `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  a: 2
+extra: b`,
+			strict: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{StrictTopLevelKeys: tc.strict}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if tc.err != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, tc.err, err.Error())
+				assert.Equal(t, ErrorKindUnknownField, err.(*Error).Kind)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
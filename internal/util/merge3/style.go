@@ -0,0 +1,229 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"bytes"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DocumentStyle describes the formatting conventions of a document.
+type DocumentStyle struct {
+	// Indent is the number of spaces nested mappings are indented by.
+	Indent int
+
+	// IndentSequences is true if sequences nested in mappings are indented
+	// under their key, rather than starting in the same column as the key.
+	IndentSequences bool
+
+	// StringStyle is the style most string values are written with, e.g.
+	// yaml.DoubleQuotedStyle, or 0 if they are mostly plain.
+	StringStyle yaml.Style
+}
+
+// DetectStyle returns the predominant style of the document.
+func DetectStyle(node *yaml.RNode) DocumentStyle {
+	var d styleDetector
+	if node != nil {
+		d.visit(node.YNode())
+	}
+	return d.style()
+}
+
+// styleDetector counts the occurrences of each style in a document.
+type styleDetector struct {
+	indents         map[int]int
+	indented, flush int
+	strings         map[yaml.Style]int
+}
+
+func (d *styleDetector) visit(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			d.visitField(key, value)
+		}
+	}
+	for _, c := range node.Content {
+		d.visit(c)
+	}
+}
+
+func (d *styleDetector) visitField(key, value *yaml.Node) {
+	switch {
+	case value.Style&yaml.FlowStyle != 0 || value.Line == key.Line:
+		// not nested under the key
+		if value.Kind == yaml.ScalarNode && value.ShortTag() == yaml.NodeTagString {
+			if d.strings == nil {
+				d.strings = map[yaml.Style]int{}
+			}
+			d.strings[value.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle)]++
+		}
+	case value.Kind == yaml.MappingNode && len(value.Content) > 0:
+		if indent := value.Content[0].Column - key.Column; indent > 0 {
+			if d.indents == nil {
+				d.indents = map[int]int{}
+			}
+			d.indents[indent]++
+		}
+	case value.Kind == yaml.SequenceNode && len(value.Content) > 0:
+		if value.Column > key.Column {
+			d.indented++
+		} else {
+			d.flush++
+		}
+	}
+}
+
+func (d *styleDetector) style() DocumentStyle {
+	s := DocumentStyle{Indent: 2}
+	for indent, count := range d.indents {
+		if count > d.indents[s.Indent] || (count == d.indents[s.Indent] && indent < s.Indent) {
+			s.Indent = indent
+		}
+	}
+	s.IndentSequences = d.indented > d.flush
+	for style, count := range d.strings {
+		if style == 0 {
+			continue
+		}
+		// ties between quoting styles are broken by the style, so that the
+		// choice doesn't depend on the map order
+		if count > d.strings[s.StringStyle] ||
+			(count == d.strings[s.StringStyle] && s.StringStyle != 0 && style < s.StringStyle) {
+			s.StringStyle = style
+		}
+	}
+	return s
+}
+
+// applyStringStyle writes the plain string values in node which are not in
+// the dest nodes with the style.
+func applyStringStyle(node *yaml.Node, dest map[*yaml.Node]bool, style yaml.Style) {
+	if style == 0 || node == nil {
+		return
+	}
+	for i, c := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			// keys are left as they are
+			continue
+		}
+		if c.Kind == yaml.ScalarNode && c.Style == 0 && !dest[c] && c.ShortTag() == yaml.NodeTagString {
+			c.Style = style
+		}
+		applyStringStyle(c, dest, style)
+	}
+}
+
+// nodeSet returns the set of nodes in the tree.
+func nodeSet(node *yaml.Node, set map[*yaml.Node]bool) map[*yaml.Node]bool {
+	if node == nil {
+		return set
+	}
+	set[node] = true
+	for _, c := range node.Content {
+		nodeSet(c, set)
+	}
+	return set
+}
+
+// encode returns node serialized with the style.  The serializer only
+// indents sequences nested in mappings (by 2 spaces less than Indent) when
+// Indent is more than 2 spaces, so the sequences are re-indented afterwards
+// when IndentSequences doesn't match this.
+func (s DocumentStyle) encode(node *yaml.RNode) (string, error) {
+	var b bytes.Buffer
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(s.Indent)
+	if err := e.Encode(node.YNode()); err != nil {
+		return "", err
+	}
+	if err := e.Close(); err != nil {
+		return "", err
+	}
+	switch {
+	case s.IndentSequences && s.Indent <= 2:
+		return indentSequences(b.String(), 2)
+	case !s.IndentSequences && s.Indent > 2:
+		return indentSequences(b.String(), 2-s.Indent)
+	default:
+		return b.String(), nil
+	}
+}
+
+// indentSequences shifts the lines of the block sequences nested in
+// mappings in the serialized document by delta columns.  Blank lines are
+// left as they are, so that the content of block scalars is unchanged.
+func indentSequences(doc string, delta int) (string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		return "", err
+	}
+	lines := strings.Split(doc, "\n")
+	shifts := make([]int, len(lines))
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if value.Kind != yaml.SequenceNode || value.Style&yaml.FlowStyle != 0 || len(value.Content) == 0 {
+					continue
+				}
+				start, end := value.Line-1, sequenceEnd(lines, value.Line-1, key.Column-1)
+				for l := start; l < end; l++ {
+					shifts[l] += delta
+				}
+			}
+		}
+		for _, c := range node.Content {
+			visit(c)
+		}
+	}
+	visit(&root)
+
+	for i, line := range lines {
+		switch {
+		case shifts[i] == 0 || strings.TrimSpace(line) == "":
+		case shifts[i] > 0:
+			lines[i] = strings.Repeat(" ", shifts[i]) + line
+		case strings.HasPrefix(line, strings.Repeat(" ", -shifts[i])):
+			lines[i] = line[-shifts[i]:]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// sequenceEnd returns the index of the line after the block sequence which
+// starts at the line start, and which is the value of a mapping key at the
+// column.  The sequence ends at the first line which is less indented than
+// the sequence, other than blank and comment lines which are followed by
+// more of the sequence.
+func sequenceEnd(lines []string, start, column int) int {
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(lines[i]) - len(trimmed)
+		if indent < column || (indent == column && trimmed != "-" && !strings.HasPrefix(trimmed, "- ")) {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestDetectStyle(t *testing.T) {
+	var testCases = []struct {
+		description string
+		input       string
+		expected    DocumentStyle
+	}{
+		{
+			description: `compact`,
+			input: `
+kind: Foo
+spec:
+  args:
+  - a
+  template:
+    name: foo
+`,
+			expected: DocumentStyle{Indent: 2},
+		},
+		{
+			description: `indented`,
+			input: `
+kind: "Foo"
+spec:
+    args:
+        - "a"
+    template:
+        name: "foo"
+        image: nginx
+`,
+			expected: DocumentStyle{Indent: 4, IndentSequences: true, StringStyle: yaml.DoubleQuotedStyle},
+		},
+		{
+			description: `single quoted`,
+			input: `
+kind: 'Foo'
+spec:
+  name: 'foo'
+`,
+			expected: DocumentStyle{Indent: 2, StringStyle: yaml.SingleQuotedStyle},
+		},
+		{
+			description: `tied quoting styles`,
+			input: `
+a: "x"
+b: 'y'
+`,
+			expected: DocumentStyle{Indent: 2, StringStyle: yaml.DoubleQuotedStyle},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectStyle(yaml.MustParse(tc.input)))
+		})
+	}
+}
+
+func TestVisitor_MatchDestStyle(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  image: nginx
+  args:
+  - --port
+  template:
+    name: foo
+`
+	local := `
+kind: "Foo"
+spec:
+    replicas: 1
+    labels:
+        app: "foo"
+    finalizers:
+      - "foo"
+`
+	expected := `
+kind: "Foo"
+spec:
+    replicas: 2
+    labels:
+        app: "foo"
+    finalizers:
+      - "foo"
+    args:
+      - "--port"
+    image: "nginx"
+    template:
+        name: "foo"
+`
+
+	actual, _, err := Visitor{MatchDestStyle: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+
+	// without the option the result uses the default style
+	actual, _, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+kind: "Foo"
+spec:
+  replicas: 2
+  labels:
+    app: "foo"
+  finalizers:
+  - "foo"
+  args:
+  - --port
+  image: nginx
+  template:
+    name: foo
+`), strings.TrimSpace(actual))
+}
+
+func TestVisitor_MatchDestStyle_sequences(t *testing.T) {
+	origin := `
+kind: Foo
+spec:
+  replicas: 1
+`
+	update := `
+kind: Foo
+spec:
+  replicas: 2
+  containers:
+  - name: nginx
+    args:
+    - --port
+    script: |
+      - not a list
+       indented
+  - name: sidecar
+`
+
+	var testCases = []struct {
+		description string
+		local       string
+		expected    string
+	}{
+		{
+			description: `indented sequences with a 2 space indent`,
+			local: `
+kind: Foo
+spec:
+  replicas: 1
+  finalizers:
+    - foo
+`,
+			expected: `
+kind: Foo
+spec:
+  replicas: 2
+  finalizers:
+    - foo
+  containers:
+    - name: nginx
+      args:
+        - --port
+      script: |
+        - not a list
+         indented
+    - name: sidecar
+`,
+		},
+		{
+			description: `flush sequences with a 4 space indent`,
+			local: `
+kind: Foo
+spec:
+    replicas: 1
+    finalizers:
+    - foo
+`,
+			expected: `
+kind: Foo
+spec:
+    replicas: 2
+    finalizers:
+    - foo
+    containers:
+    - name: nginx
+      args:
+      - --port
+      script: |
+          - not a list
+           indented
+    - name: sidecar
+`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{MatchDestStyle: true}.MergeStrings(tc.local, origin, update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
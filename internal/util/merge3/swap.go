@@ -0,0 +1,93 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// scalarFields returns the values of the scalar fields of each source map,
+// indexed like walk.Sources, if WarnOnSwappedValues is set.  They are read
+// before the merge modifies dest.
+func (l walker) scalarFields() []map[string]string {
+	if !l.visitor.WarnOnSwappedValues || l.visitor.report == nil {
+		return nil
+	}
+	values := make([]map[string]string, len(l.sources))
+	for i, s := range l.sources {
+		values[i] = scalarValues(s)
+	}
+	return values
+}
+
+// scalarValues returns the values of the scalar fields of the map node.
+func scalarValues(node *yaml.RNode) map[string]string {
+	values := map[string]string{}
+	if yaml.IsMissingOrNull(node) || node.YNode().Kind != yaml.MappingNode {
+		return values
+	}
+	content := node.YNode().Content
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i+1].Kind == yaml.ScalarNode {
+			values[content[i].Value] = content[i+1].Value
+		}
+	}
+	return values
+}
+
+// checkSwaps records a warning for each pair of fields of the merged map
+// whose origin values were swapped by the merge, with update changing one of
+// the fields and dest the other.  Each field is merged independently, so
+// neither update nor dest may have intended the swap.
+func (l walker) checkSwaps(values []map[string]string, result *yaml.RNode) {
+	if values == nil {
+		return
+	}
+	dest, origin, update := values[walk.DestIndex], values[walk.OriginIndex], values[walk.UpdatedIndex]
+	merged := scalarValues(result)
+	var fields []string
+	for f := range origin {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	swapped := func(v map[string]string, a, b string) bool {
+		return v[a] == origin[b] && v[b] == origin[a]
+	}
+	for i, a := range fields {
+		for _, b := range fields[i+1:] {
+			if origin[a] == origin[b] || !swapped(merged, a, b) ||
+				swapped(dest, a, b) || swapped(update, a, b) {
+				continue
+			}
+			changedByUpdate, changedByDest := a, b
+			if update[a] == origin[a] {
+				changedByUpdate, changedByDest = b, a
+			}
+			l.visitor.report.Warnings = append(l.visitor.report.Warnings, fmt.Sprintf(
+				"%s and %s swapped values, because update changed %s and dest changed %s",
+				l.fieldPath(a), l.fieldPath(b), l.fieldPath(changedByUpdate), l.fieldPath(changedByDest)))
+		}
+	}
+}
+
+// fieldPath returns the path of the field of the map walked by l.
+func (l walker) fieldPath(field string) string {
+	return pathString(append(append([]string{}, l.path...), field))
+}
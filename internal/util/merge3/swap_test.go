@@ -0,0 +1,134 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_WarnOnSwappedValues(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		warnings    []string
+	}{
+		{
+			description: `update and dest each change one field to the other's value`,
+			origin: `
+spec:
+  primary: east
+  secondary: west`,
+			update: `
+spec:
+  primary: west
+  secondary: west`,
+			local: `
+spec:
+  primary: east
+  secondary: east`,
+			expected: `
+spec:
+  primary: west
+  secondary: east`,
+			warnings: []string{
+				"spec.primary and spec.secondary swapped values, because update changed spec.primary and dest changed spec.secondary",
+			},
+		},
+		{
+			description: `the change by dest is reported for either field`,
+			origin: `
+spec:
+  primary: east
+  secondary: west`,
+			update: `
+spec:
+  primary: east
+  secondary: east`,
+			local: `
+spec:
+  primary: west
+  secondary: west`,
+			expected: `
+spec:
+  primary: west
+  secondary: east`,
+			warnings: []string{
+				"spec.primary and spec.secondary swapped values, because update changed spec.secondary and dest changed spec.primary",
+			},
+		},
+		{
+			description: `swap in update alone isn't reported`,
+			origin: `
+spec:
+  primary: east
+  secondary: west`,
+			update: `
+spec:
+  primary: west
+  secondary: east`,
+			local: `
+spec:
+  primary: east
+  secondary: west
+  other: a`,
+			expected: `
+spec:
+  primary: west
+  secondary: east
+  other: a`,
+		},
+		{
+			description: `unrelated changes aren't reported`,
+			origin: `
+spec:
+  primary: east
+  secondary: west`,
+			update: `
+spec:
+  primary: north
+  secondary: west`,
+			local: `
+spec:
+  primary: east
+  secondary: south`,
+			expected: `
+spec:
+  primary: north
+  secondary: south`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, report, err := Visitor{WarnOnSwappedValues: true}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			// each field is merged independently
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+			assert.Empty(t, report.Conflicts)
+			assert.Equal(t, tc.warnings, report.Warnings)
+		})
+	}
+}
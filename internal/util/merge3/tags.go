@@ -0,0 +1,38 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// keepCollectionTag sets the tag of the merged collection node to the tag of
+// the dest collection, if dest has an explicit tag such as `!!omap`.
+func (l walker) keepCollectionTag(node *yaml.RNode, err error) (*yaml.RNode, error) {
+	if err != nil || !l.visitor.KeepCollectionTags || yaml.IsMissingOrNull(node) {
+		return node, err
+	}
+	dest := l.sources.Dest()
+	if yaml.IsMissingOrNull(dest) || dest.YNode().Kind != node.YNode().Kind {
+		return node, nil
+	}
+	switch tag := dest.YNode().Tag; tag {
+	case "", yaml.NodeTagMap, yaml.NodeTagSeq:
+		// the default tags are resolved from the kind
+	default:
+		node.YNode().Tag = tag
+	}
+	return node, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_KeepCollectionTags(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		keepTags    bool
+	}{
+		{
+			description: `tagged list replaced by update keeps the dest tag`,
+			origin: `
+list: [1]`,
+			update: `
+list: [1, 2]`,
+			local: `
+list: !ordered
+- 1`,
+			expected: `
+list: !ordered
+- 1
+- 2`,
+			keepTags: true,
+		},
+		{
+			description: `untagged list replaced by update takes update`,
+			origin: `
+list: [1]`,
+			update: `
+list: [1, 2]`,
+			local: `
+list:
+- 1`,
+			expected: `
+list:
+- 1
+- 2`,
+			keepTags: true,
+		},
+		{
+			description: `tagged map merged with update keeps the dest tag`,
+			origin: `
+map: {a: 1}`,
+			update: `
+map: {a: 2, b: 3}`,
+			local: `
+map: !custom
+  a: 1
+  c: 4`,
+			expected: `
+map: !custom
+  a: 2
+  c: 4
+  b: 3`,
+			keepTags: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{KeepCollectionTags: tc.keepTags}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_KeepCollectionTags_omap(t *testing.T) {
+	origin := yaml.MustParse(`
+steps:
+- build: a`)
+	update := yaml.MustParse(`
+steps:
+- build: a
+- test: b`)
+	local := yaml.MustParse(`
+steps: !!omap
+- build: a
+- deploy: c`)
+
+	actual, _, err := Visitor{KeepCollectionTags: true}.Merge(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	steps, err := actual.Pipe(yaml.Lookup("steps"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "!!omap", steps.YNode().Tag)
+	assert.Len(t, steps.Content(), 2)
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"time"
+)
+
+// timedOut returns true if the merge has run for longer than the Timeout.
+// The first time it does, a warning with the path of the first field left
+// unmerged is recorded.
+func (m Visitor) timedOut(path []string) bool {
+	if m.deadline.IsZero() {
+		return false
+	}
+	if m.report.TimedOut {
+		return true
+	}
+	if time.Now().Before(m.deadline) {
+		return false
+	}
+	m.report.TimedOut = true
+	m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(
+		"timed out after %s, %s and the fields after it were left unmerged",
+		m.Timeout, pathString(path)))
+	return true
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_Timeout(t *testing.T) {
+	origin := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7
+  paused: false
+`
+	update := `
+kind: Deployment
+spec:
+  replicas: 2
+  image: nginx:1.8
+  paused: true
+  minReadySeconds: 5
+`
+	local := `
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.7
+  paused: false
+`
+
+	// a slow hook makes the merge run past the timeout
+	slow := func(value *yaml.RNode) (*yaml.RNode, error) {
+		time.Sleep(50 * time.Millisecond)
+		return value, nil
+	}
+	visitor := Visitor{
+		Timeout:    10 * time.Millisecond,
+		Transforms: map[string]Transform{"spec.image": slow},
+	}
+	actual, report, err := visitor.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the fields walked after the slow hook are left unmerged
+	assert.Equal(t, strings.TrimSpace(`
+kind: Deployment
+spec:
+  replicas: 1
+  image: nginx:1.8
+  paused: false
+`), strings.TrimSpace(actual))
+	assert.True(t, report.TimedOut)
+	assert.Equal(t, []string{
+		"timed out after 10ms, spec.minReadySeconds and the fields after it were left unmerged",
+	}, report.Warnings)
+
+	// the merge completes within the timeout
+	visitor.Timeout = time.Minute
+	actual, report, err = visitor.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(update), strings.TrimSpace(actual))
+	assert.False(t, report.TimedOut)
+	assert.Empty(t, report.Warnings)
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Transform returns the value to use in place of the merged value of a
+// field.
+type Transform func(value *yaml.RNode) (*yaml.RNode, error)
+
+// transform applies the Transform configured for path to the merged value
+// of the field.  Transforms are looked up by the path of the field, and then
+// by its path pattern, e.g. `spec.containers[*].image`.
+func (m Visitor) transform(path []string, node *yaml.RNode, err error) (*yaml.RNode, error) {
+	if err != nil || len(m.Transforms) == 0 || yaml.IsMissingOrNull(node) {
+		return node, err
+	}
+	p := pathString(path)
+	key := p
+	t, found := m.Transforms[key]
+	if !found {
+		key = pathPattern(p)
+		if t, found = m.Transforms[key]; !found {
+			return node, nil
+		}
+	}
+	m.matchRule("Transforms: "+key, path)
+	result, err := t(node)
+	if err != nil {
+		return nil, errors.WrapPrefixf(err, "%s", p)
+	}
+	return result, nil
+}
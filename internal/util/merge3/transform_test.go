@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_Transforms(t *testing.T) {
+	registry := func(value *yaml.RNode) (*yaml.RNode, error) {
+		image := value.YNode().Value
+		if !strings.HasPrefix(image, "gcr.io/") {
+			image = "gcr.io/" + image
+		}
+		return yaml.NewScalarRNode(image), nil
+	}
+
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		transforms  map[string]Transform
+	}{
+		{
+			description: `winning update image gets a registry prefix`,
+			origin: `
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: sidecar:1.0`,
+			update: `
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.8
+  - name: sidecar
+    image: sidecar:1.0`,
+			local: `
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.7
+  - name: sidecar
+    image: gcr.io/sidecar:2.0`,
+			expected: `
+spec:
+  containers:
+  - name: nginx
+    image: gcr.io/nginx:1.8
+  - name: sidecar
+    image: gcr.io/sidecar:2.0`,
+			transforms: map[string]Transform{
+				"spec.containers[*].image": registry,
+			},
+		},
+		{
+			description: `exact path`,
+			origin: `
+spec:
+  host: example.com
+  backup: example.com`,
+			update: `
+spec:
+  host: example.org
+  backup: example.org`,
+			local: `
+spec:
+  host: example.com
+  backup: example.com`,
+			expected: `
+spec:
+  host: internal.example.org
+  backup: example.org`,
+			transforms: map[string]Transform{
+				"spec.host": func(value *yaml.RNode) (*yaml.RNode, error) {
+					return yaml.NewScalarRNode("internal." + value.YNode().Value), nil
+				},
+			},
+		},
+		{
+			description: `deleted field isn't transformed`,
+			origin: `
+spec:
+  host: example.com`,
+			update: `
+spec: {}`,
+			local: `
+spec:
+  host: example.com`,
+			expected: `
+spec: {}`,
+			transforms: map[string]Transform{
+				"spec.host": func(value *yaml.RNode) (*yaml.RNode, error) {
+					return yaml.NewScalarRNode("internal." + value.YNode().Value), nil
+				},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InferAssociativeLists: true, Transforms: tc.transforms}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
+
+func TestVisitor_Transforms_error(t *testing.T) {
+	_, _, err := Visitor{Transforms: map[string]Transform{
+		"spec.host": func(*yaml.RNode) (*yaml.RNode, error) {
+			return nil, fmt.Errorf("host not allowed")
+		},
+	}}.MergeStrings(`spec: {host: a}`, `spec: {host: a}`, `spec: {host: b}`)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, err.Error(), "spec.host: host not allowed")
+}
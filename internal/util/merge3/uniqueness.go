@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// checkUniqueKeys returns an error if two elements of the merged associative
+// list share the same merge key values.  Elements without any merge key
+// values are ignored.
+func (m Visitor) checkUniqueKeys(dest *yaml.RNode, path []string, keys []string) error {
+	if !m.ValidateMergeKeys {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, elem := range dest.Content() {
+		values := elementKeyValues(elem, keys)
+		if strings.Join(values, "") == "" {
+			// elements without any merge key aren't identified by it
+			continue
+		}
+		id := strings.Join(values, "\x00")
+		if seen[id] {
+			return &Error{
+				Path: pathString(path),
+				Kind: ErrorKindDuplicateMergeKey,
+				Message: fmt.Sprintf("list %s contains more than one element with %s",
+					pathString(path), elementSegment(keys, values)),
+			}
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// elementKeyValues returns the values of the merge keys on the list element.
+// Missing keys have empty values.
+func elementKeyValues(elem *yaml.Node, keys []string) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			// primitive list -- the value is the identity
+			values[i] = elem.Value
+			continue
+		}
+		if field := yaml.NewRNode(elem).Field(key); field != nil && field.Value != nil {
+			values[i] = field.Value.YNode().Value
+		}
+	}
+	return values
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_ValidateMergeKeys(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		expected    string
+		validate    bool
+		err         string
+	}{
+		{
+			description: `duplicated element is caught`,
+			origin: `
+items:
+- name: a
+  value: 1`,
+			update: `
+items:
+- name: a
+  value: 2`,
+			local: `
+items:
+- name: a
+  value: 1
+- name: a
+  value: 3`,
+			validate: true,
+			err:      "list items contains more than one element with [name=a]",
+		},
+		{
+			description: `duplicated primitive element is caught`,
+			origin: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  finalizers: [a]`,
+			update: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  finalizers: [a, b]`,
+			local: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  finalizers: [a, c, c]`,
+			validate: true,
+			err:      "list metadata.finalizers contains more than one element with [c]",
+		},
+		{
+			description: `unique elements are merged`,
+			origin: `
+items:
+- name: a
+  value: 1`,
+			update: `
+items:
+- name: a
+  value: 2
+- name: b
+  value: 1`,
+			local: `
+items:
+- name: a
+  value: 1
+- name: c
+  value: 1`,
+			expected: `
+items:
+- name: a
+  value: 2
+- name: c
+  value: 1
+- name: b
+  value: 1`,
+			validate: true,
+		},
+		{
+			description: `elements without a merge key aren't duplicates`,
+			origin: `
+items:
+- name: a
+  value: 1`,
+			update: `
+items:
+- name: a
+  value: 2`,
+			local: `
+items:
+- name: a
+  value: 1
+- value: 3
+- value: 4`,
+			expected: `
+items:
+- value: 3
+- value: 4
+- name: a
+  value: 2`,
+			validate: true,
+		},
+		{
+			description: `duplicated element is mis-merged without the option`,
+			origin: `
+items:
+- name: a
+  value: 1`,
+			update: `
+items:
+- name: a
+  value: 1`,
+			local: `
+items:
+- name: a
+  value: 1
+- name: a
+  value: 3`,
+			expected: `
+items:
+- name: a
+  value: 1
+- name: a
+  value: 1`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{InferAssociativeLists: true, ValidateMergeKeys: tc.validate}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if tc.err != "" {
+				if assert.EqualError(t, err, tc.err) {
+					assert.Equal(t, ErrorKindDuplicateMergeKey, err.(*Error).Kind)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
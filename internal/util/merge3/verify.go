@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// verifyOutput returns an error if the serialized output doesn't parse back
+// to the content of result.
+func verifyOutput(output string, result *yaml.RNode) error {
+	var parsed *yaml.RNode
+	if strings.TrimSpace(output) != "" {
+		var err error
+		if parsed, err = yaml.Parse(output); err != nil {
+			return &Error{
+				Kind:    ErrorKindInvalidOutput,
+				Message: fmt.Sprintf("merged output is not valid YAML: %v", err),
+			}
+		}
+	}
+	want, err := Hash(result)
+	if err != nil {
+		return err
+	}
+	got, err := Hash(parsed)
+	if err != nil {
+		return &Error{
+			Kind:    ErrorKindInvalidOutput,
+			Message: fmt.Sprintf("merged output is not valid YAML: %v", err),
+		}
+	}
+	if got != want {
+		return &Error{
+			Kind:    ErrorKindInvalidOutput,
+			Message: "merged output does not parse back to the merged result",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitor_VerifyOutput(t *testing.T) {
+	var testCases = []struct {
+		description string
+		origin      string
+		update      string
+		local       string
+		visitor     Visitor
+		expected    string
+		err         string
+	}{
+		{
+			description: `valid output passes the check`,
+			origin: `
+a: &a 1 # a comment
+b: |
+  text
+`,
+			update: `
+a: &a 2 # a comment
+b: |
+  text
+`,
+			local: `
+a: &a 1 # a comment
+b: |
+  text
+c: 3
+`,
+			visitor: Visitor{VerifyOutput: true},
+			expected: `
+a: &a 2 # a comment
+b: |
+  text
+c: 3
+`,
+		},
+		{
+			// the block scalar line looks like the line with the aligned
+			// comment that update removed, but isn't a comment
+			description: `output with aligned comments passes the check`,
+			origin: `
+x:
+  a: 1 # c
+  b: 2
+s: |
+  a: 1 # c
+`,
+			update: `
+x:
+  b: 2
+s: |
+  a: 1 # c
+`,
+			local: `
+x:
+  a: 1   # c
+  b: 2
+s: |
+  a: 1 # c
+`,
+			visitor: Visitor{VerifyOutput: true, AlignComments: true},
+			expected: `
+x:
+  b: 2
+s: |
+  a: 1 # c
+`,
+		},
+		{
+			// stripping the trailing newline of the document removes the
+			// final line break of the block scalar
+			description: `output changed by the trailing newline policy fails the check`,
+			origin: `
+a: 1
+s: |
+  text
+`,
+			update: `
+a: 2
+s: |
+  text
+`,
+			local: `
+a: 1
+s: |
+  text
+`,
+			visitor: Visitor{VerifyOutput: true, TrailingNewline: TrailingNewlineStrip},
+			err:     `merged output does not parse back to the merged result`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := tc.visitor.MergeStrings(tc.local, tc.origin, tc.update)
+			if tc.err != "" {
+				if !assert.EqualError(t, err, tc.err) {
+					t.FailNow()
+				}
+				assert.Equal(t, ErrorKindInvalidOutput, err.(*Error).Kind)
+				assert.Empty(t, actual)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
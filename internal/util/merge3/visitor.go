@@ -0,0 +1,1040 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package merge3
+
+import (
+	"regexp"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// ConflictStrategy controls which value is kept when a field was changed in
+// both dest and update.
+type ConflictStrategy uint
+
+const (
+	// TakeUpdate keeps the update value.  It is the default.
+	TakeUpdate ConflictStrategy = 1 + iota
+
+	// TakeDest keeps the dest value.
+	TakeDest
+)
+
+// Visitor performs a three-way merge of the dest, origin and updated nodes
+// passed to it by the walker.
+type Visitor struct {
+	// ConflictStrategy controls which value is kept when a field was changed
+	// in both dest and update.  Defaults to TakeUpdate.
+	ConflictStrategy ConflictStrategy
+
+	// OnConflict if set is called with each Conflict as soon as it is found,
+	// before the merge continues, so that it can be resolved interactively.
+	// It returns the ConflictStrategy used to resolve the Conflict, or zero
+	// to use ConflictStrategy.  Returning an error aborts the merge.
+	OnConflict func(Conflict) (ConflictStrategy, error)
+
+	// InferAssociativeLists if set to true will infer merge strategies for
+	// fields which it doesn't have the schema based on the fields in the
+	// list elements.
+	InferAssociativeLists bool
+
+	// StrategicMergePatch if set to true merges lists the way a Kubernetes
+	// strategic merge patch would, for compatibility with tooling built on
+	// strategic merge patches.  Only lists with a patchStrategy of merge in
+	// the schema are merged, using the patchMergeKey to identify elements.
+	// All other lists are replaced.  InferAssociativeLists is ignored.
+	StrategicMergePatch bool
+
+	// ExplicitNullDeletes if set to true only deletes a field from dest when
+	// update explicitly sets it to null (e.g. `foo: null`, `foo: ~` or
+	// `foo: !!null`) and origin did not.  An implicit empty value in update
+	// (e.g. `foo:`) is treated the same as the field being absent.
+	ExplicitNullDeletes bool
+
+	// SortListsBy maps the path of an associative list (e.g. `spec.rules`) to
+	// the name of a field on its elements.  After the elements of the list are
+	// merged, they are sorted by the value of this field so that elements
+	// added by update are placed in order.  Elements missing the field are
+	// sorted last.
+	SortListsBy map[string]string
+
+	// SortListsByKey contains the paths of associative lists (e.g.
+	// `metadata.finalizers`) whose elements are sorted by their merge keys
+	// after they are merged, and after the field configured in SortListsBy,
+	// so that the order of lists whose order is insignificant doesn't depend
+	// on the order of the sources.  Lists whose order is significant, such
+	// as containers or env, should not be configured here.
+	SortListsByKey []string
+
+	// SecondarySortBy maps the path of an associative list to the name of a
+	// field on its elements, which the elements are sorted by after the
+	// field configured in SortListsBy.  It is used instead of the merge keys
+	// if the list is in SortListsByKey.
+	SecondarySortBy map[string]string
+
+	// Transforms maps the path of a field (e.g. `spec.replicas`) or a path
+	// pattern (e.g. `spec.containers[*].image`) to a Transform which is
+	// applied to the merged value of the field, e.g. to prefix images with a
+	// registry.
+	Transforms map[string]Transform
+
+	// SequencesToMaps maps the path of a field (e.g. `spec.env`) or a path
+	// pattern which update converted from a sequence to a map, to the field
+	// of the elements which update uses as the map key (e.g. `name`).  The
+	// elements of the sequences in dest and origin are converted to map
+	// entries without the key field, so that the dest customizations are
+	// carried across the change.
+	SequencesToMaps map[string]string
+
+	// ServerDefaults maps the kind of a resource (e.g. `Deployment`) to the
+	// paths or path patterns of the fields which the API server defaults,
+	// and their default values, e.g. `spec.template.spec.restartPolicy` to
+	// `Always`.  A field which dest has with the default value, and origin
+	// doesn't have, is merged as if dest didn't have it, so that resources
+	// read back from a cluster don't conflict with update.
+	ServerDefaults map[string]map[string]string
+
+	// ImmutableFields maps the kind of a resource (e.g. `Deployment`) to the
+	// paths of the fields which can't be changed once the resource is
+	// created, e.g. `spec.selector`.  Changes by update to these fields in
+	// an existing dest are merged according to ImmutableFieldChanges.
+	ImmutableFields map[string][]string
+
+	// ImmutableFieldChanges controls how changes by update to the
+	// ImmutableFields are merged.  Defaults to ImmutableFieldsError.
+	ImmutableFieldChanges ImmutableFieldPolicy
+
+	// MaxListGrowth if non-zero is the maximum number of elements an
+	// associative list may gain in a single merge.  This guards against
+	// misconfigured merge keys which duplicate elements.  The merge fails if
+	// the limit is exceeded, unless WarnOnMaxListGrowth is set.
+	MaxListGrowth int
+
+	// MaxAdditionsPerKey if non-zero is the maximum number of elements with
+	// the same value for their (first) merge key which may be added to an
+	// associative list in a single merge.  This guards against mis-inferred
+	// merge keys which add the same logical element many times.
+	MaxAdditionsPerKey int
+
+	// WarnOnMaxListGrowth if set to true records a warning in Report.Warnings
+	// rather than failing the merge when MaxListGrowth is exceeded.
+	WarnOnMaxListGrowth bool
+
+	// ValidateMergeKeys if set to true fails the merge if two elements of a
+	// merged associative list share the same merge key values.  This catches
+	// elements duplicated by a mis-merge, independent of the inputs.
+	ValidateMergeKeys bool
+
+	// CommentConflicts if set to true records the comments on a conflicting
+	// field in Conflict.Comments when its comment was also changed to
+	// different values in dest and update.
+	CommentConflicts bool
+
+	// MaxConflictValueLength if set truncates the values of each Conflict in
+	// the JSON report to this many characters, followed by `...`.  The
+	// Conflict fields contain the full values.
+	MaxConflictValueLength int
+
+	// RecordConflictReasons if set to true records in Conflict.Reason how
+	// each Conflict was resolved, e.g. by the ConflictStrategy or by
+	// OnConflict.
+	RecordConflictReasons bool
+
+	// GroupConflicts if set to true groups the conflicts which differ only by
+	// the associative list elements in their paths, and records the groups
+	// in Report.ConflictGroups.
+	GroupConflicts bool
+
+	// PreviewAdditions if set to true records a document containing only the
+	// fields and list elements which update added to dest in
+	// Report.Additions, so they can be reviewed before accepting the merge.
+	PreviewAdditions bool
+
+	// DuplicateKeys controls how maps with duplicate keys in any of the
+	// sources are handled.  Defaults to DuplicateKeysError.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// MergeSource controls how the kpt.dev/merge-source annotation is merged.
+	// Defaults to MergeSourceFromUpdate.
+	MergeSource MergeSourcePolicy
+
+	// OrderedSetLists contains the paths of lists of scalars (e.g.
+	// `spec.args`) which are merged as ordered sets: the dest elements are
+	// kept in order, followed by the elements update added in update's
+	// order.  Elements update removed from origin are removed from dest.
+	OrderedSetLists []string
+
+	// Status controls how the top-level `status` field of resources is
+	// merged.  Defaults to StatusMerge.
+	Status StatusPolicy
+
+	// NormalizeNumbers if set to true compares ints and floats by value, so
+	// that changing only how a number is written (e.g. `1.5` to `1.50`) is
+	// not treated as a change.
+	NormalizeNumbers bool
+
+	// NormalizeMergeKeys if set to true matches associative list elements
+	// whose merge key values are the same number written differently in
+	// each source, e.g. `8080`, `"8080"` and `0x1F90`.  The elements keep
+	// the key value written in dest.
+	NormalizeMergeKeys bool
+
+	// CanonicalFloats if set to true with NormalizeNumbers compares floats by
+	// a canonical rendering which is the same on every platform, and which
+	// also covers `.inf` and `.nan`, so that e.g. `1e6` equals `1000000` and
+	// `.Inf` equals `.inf`.
+	CanonicalFloats bool
+
+	// WriteCanonicalFloats if set to true writes the merged floats in the
+	// canonical rendering used by CanonicalFloats.
+	WriteCanonicalFloats bool
+
+	// AdoptNumberFormatting if set to true with NormalizeNumbers takes the
+	// way update writes a number when update only changed its formatting and
+	// dest has the same value as origin.  Otherwise the dest formatting is
+	// kept.
+	AdoptNumberFormatting bool
+
+	// AdoptBlockStyle if set to true takes update when it only changed a
+	// scalar to or from a multi-line block scalar (e.g. `"a\nb\n"` to `|`)
+	// without changing its value, and dest has the same value and style as
+	// origin.  Otherwise the dest style is kept.
+	AdoptBlockStyle bool
+
+	// PreserveChomping if set to true keeps the chomping indicator (`|-`, `|`
+	// or `|+`) of a dest block scalar when its value is taken from update
+	// and update has the same block style, so that the trailing newlines of
+	// the value don't change.  Changes by update to the chomping indicator
+	// of origin are merged like changes to the value.
+	PreserveChomping bool
+
+	// KeepCollectionTags if set to true keeps the explicit tag of dest maps
+	// and lists, e.g. `!!omap`, when the merged value is taken from update.
+	KeepCollectionTags bool
+
+	// StrictTopLevelKeys if set to true fails the merge if a resource with a
+	// schema has a top-level key which isn't a field of the schema.
+	StrictTopLevelKeys bool
+
+	// InitializeNullParents if set to true merges the fields update adds to
+	// a map which is null in dest into a new map, rather than deleting the
+	// map, unless origin had the map and dest cleared it.
+	InitializeNullParents bool
+
+	// SkipIdenticalInputs if set to true makes Merge and MergeStrings return
+	// dest unchanged, and an empty Report, if dest, original and update are
+	// identical, rather than merging and reformatting them.  MergeStrings
+	// compares the inputs before parsing them.
+	SkipIdenticalInputs bool
+
+	// IgnoreQuoting if set to true compares values without the quoting of
+	// their scalars, so that e.g. `[a, "80"]` and `[a, 80]` are equal.  The
+	// dest quoting is kept for values which are equal.
+	IgnoreQuoting bool
+
+	// Placeholders if set matches the values of the fields update added
+	// which are placeholders for the consumer to fill in, e.g. `REPLACE_ME`.
+	// A warning is recorded in Report.Warnings for each such field, so the
+	// consumer knows action is required.
+	Placeholders *regexp.Regexp
+
+	// ProtectedComments if set matches the comments of dest which dest
+	// tooling relies on, e.g. setter markers such as `{"$kpt-set":"image"}`.
+	// They are kept on the merged fields if update removed them.
+	ProtectedComments *regexp.Regexp
+
+	// ReadOnlyFields controls how the fields and associative list elements
+	// dest marked with a `# readonly` comment are merged.  Defaults to
+	// ReadOnlyIgnore, so that the comment is only enforced when requested.
+	ReadOnlyFields ReadOnlyFieldPolicy
+
+	// MaxMergeDepth if non-zero is the number of levels of fields and list
+	// elements which are merged.  Nodes nested below this depth are kept
+	// from dest as a whole, without merging them.  This can be used to scope
+	// a merge to the top levels of a resource.
+	MaxMergeDepth int
+
+	// MaxDrift if non-zero is the fraction of fields (between 0 and 1) which
+	// dest may have changed from origin before a warning is recorded in
+	// Report.Warnings.  Heavily customized packages may be risky to merge.
+	MaxDrift float64
+
+	// ReportDrift if set to true measures the drift of dest from origin in
+	// Report.Drift, even without a MaxDrift.
+	ReportDrift bool
+
+	// WarnOnDivergence if set to true records a warning in Report.Warnings
+	// for each field where dest keeps a value which differs from origin,
+	// while update has the origin value, e.g. because upstream reverted a
+	// change which dest kept.
+	WarnOnDivergence bool
+
+	// WarnOnSwappedValues if set to true records a warning in
+	// Report.Warnings for each pair of fields of a map whose values were
+	// swapped by the merge because update changed one of them to the value
+	// of the other, and dest the other way around.
+	WarnOnSwappedValues bool
+
+	// ClassifyChanges if set to true records the ChangeKind of each field
+	// which isn't merged field by field in Report.Changes.
+	ClassifyChanges bool
+
+	// ReversePatch if set to true records a Patch in Report.ReversePatch
+	// which restores the dest values of the fields the merge changed, so
+	// that applying it to the merged result rolls back the merge.
+	ReversePatch bool
+
+	// Audit if set records an AuditEntry for each field which isn't merged
+	// field by field in Report.Audit.  This is the most verbose report.
+	Audit *Audit
+
+	// RecordDecisions if set to true records the source chosen for each
+	// field which isn't merged field by field in Report.Decisions.
+	RecordDecisions bool
+
+	// Replay contains Decisions recorded by a previous merge.  The fields
+	// they cover take their values from the recorded sources, even if the
+	// inputs have changed, so that re-merges are deterministic.
+	Replay []Decision
+
+	// ServerFields controls how the server-managed `metadata.generation` and
+	// `metadata.resourceVersion` fields are merged.  Defaults to
+	// ServerFieldsMerge.
+	ServerFields ServerFieldPolicy
+
+	// Labels controls how the `metadata.labels` of resources are merged.
+	// Defaults to MetadataMapMerge.
+	Labels MetadataMapPolicy
+
+	// Annotations controls how the `metadata.annotations` of resources are
+	// merged.  Defaults to MetadataMapMerge.
+	Annotations MetadataMapPolicy
+
+	// LastApplied controls how the LastAppliedAnnotation of resources is
+	// merged, so that merged resources can be used with kubectl apply.
+	// Defaults to LastAppliedMerge.
+	LastApplied LastAppliedPolicy
+
+	// RestartAnnotation if set is the annotation on `spec.template` which is
+	// set to the hash of the template when the merge changes the template,
+	// e.g. to trigger a rollout of a Deployment.
+	RestartAnnotation string
+
+	// ContentIdentityLists contains the paths of lists without a merge key
+	// whose elements are identified by their entire content.  Elements are
+	// merged like OrderedSetLists, so that elements which are unchanged but
+	// reordered are kept, and only the elements update added or removed are
+	// added to or removed from dest.
+	ContentIdentityLists []string
+
+	// ListIdentities maps the path of a list (e.g. `spec.containers`) or a
+	// path pattern (e.g. `spec.containers[*].ports`) to the ListIdentity
+	// used to match its elements, so that lists in the same document can
+	// be matched differently.
+	ListIdentities map[string]ListIdentity
+
+	// CompareResolvedAnchors if set to true merges aliases like the content
+	// of their anchors, and compares values ignoring their anchor names, so
+	// that anchors renamed in update, or aliases replaced by equal content,
+	// aren't changes.  The aliases of dest are kept where the merged value
+	// still equals their anchor's.
+	CompareResolvedAnchors bool
+
+	// AppendOnlyText contains the paths of string fields which accumulate
+	// text, e.g. a changelog.  The text update appended to origin is appended
+	// to dest, rather than replacing it.
+	AppendOnlyText []string
+
+	// ConvergedAdditions controls which value is kept when dest and update
+	// both added a field with the same value.  Defaults to
+	// ConvergedTakeUpdate.
+	ConvergedAdditions ConvergedAdditionPolicy
+
+	// AddedElements controls how an associative list element which dest and
+	// update both added with the same merge key values is merged.  Defaults
+	// to AddedElementsMerge.
+	AddedElements AddedElementPolicy
+
+	// ConvergedDeletions controls how a field which origin had, and which
+	// dest and update both removed, is reported.  The field is removed
+	// without a conflict.  Defaults to ConvergedDeletionsSilent.
+	ConvergedDeletions ConvergedDeletionPolicy
+
+	// MatchDestStyle if set to true detects the predominant style of dest
+	// and formats the merged result to match it.  Strings added from update
+	// are quoted the way most dest strings are, and MergeStrings indents the
+	// result like dest.
+	MatchDestStyle bool
+
+	// KeylessElements controls how the elements of an associative list
+	// which don't have the merge key are merged.  Defaults to
+	// KeylessElementsMerge.
+	KeylessElements KeylessElementPolicy
+
+	// KeepAddedKeyOrder if set to true appends the fields update added to a
+	// map after the dest fields in the order update has them, so that related
+	// upstream additions stay grouped.  Otherwise they are appended sorted by
+	// name.
+	KeepAddedKeyOrder bool
+
+	// AdoptDescriptions if set to true always takes the head comment
+	// describing a field from update, if update has one, even if dest changed
+	// the comment.  The value of the field is merged as usual.
+	AdoptDescriptions bool
+
+	// AlignComments if set to true makes MergeStrings keep the column of the
+	// inline comments on the lines of dest which are unchanged by the merge,
+	// e.g. for comments aligned in columns.
+	AlignComments bool
+
+	// TrailingNewline controls the newlines at the end of the output of
+	// MergeStrings.  Defaults to TrailingNewlineDefault.
+	TrailingNewline TrailingNewlinePolicy
+
+	// VerifyOutput if set to true makes MergeStrings parse the merged output
+	// and fail if it isn't valid YAML with the same content as the merged
+	// result, rather than returning malformed output.
+	VerifyOutput bool
+
+	// ValidateLineage if set to true makes Merge fail if a value in the
+	// merged result isn't the value of the field in origin, dest or
+	// update, e.g. because a Transform injected it.  This is a debug mode:
+	// values computed by options such as AppendOnlyText or
+	// WriteCanonicalFloats fail the check too.  Values set after the walk,
+	// e.g. for RestartAnnotation, aren't checked.
+	ValidateLineage bool
+
+	// NullElements controls how null elements in lists are merged.
+	// Defaults to NullElementsAsValues.
+	NullElements NullElementPolicy
+
+	// PreserveNullTags if set to true keeps the representation of the null
+	// elements NullElementsByPosition retains, e.g. `!!null` or `~`, rather
+	// than writing them as `null`.
+	PreserveNullTags bool
+
+	// ReportMergeKeys if set to true records the merge keys used for each
+	// associative list, and whether they were configured or inferred, in
+	// Report.MergeKeys.
+	ReportMergeKeys bool
+
+	// RecordRuleMatches if set to true records the paths matched by each
+	// configured rule (e.g. SortListsBy, OrderedSetLists or an inline merge
+	// key hint) in Report.RuleMatches, so that policy authors can verify
+	// their rules apply to the intended fields.
+	RecordRuleMatches bool
+
+	// ReportUnchangedUpstream if set to true will record the paths of the
+	// fields which are present in origin and were not changed by update
+	// in Report.UnchangedUpstream.
+	ReportUnchangedUpstream bool
+
+	// Timeout if set stops the merge after the duration.  The fields which
+	// weren't merged yet keep their dest values, and Report.TimedOut is set.
+	Timeout time.Duration
+
+	// explain if set to true records the reason for each merge decision.
+	explain bool
+
+	// diff if set to true records the fields which were changed or
+	// conflicted for Diff.
+	diff bool
+
+	// report collects information about the merge.  It is set by Merge.
+	report *Report
+
+	// replay indexes the Replay decisions by path.  It is set by Merge.
+	replay map[string]DecisionSource
+
+	// kind is the kind of the merged resource, used to look up its
+	// ServerDefaults and ImmutableFields.  It is set by Merge.
+	kind string
+
+	// deadline is the time the merge times out at, if Timeout is set.  It is
+	// set by Merge.
+	deadline time.Time
+
+	// kindStrategy is true if the ConflictStrategy was set by a Registry for
+	// the kind of the resource.
+	kindStrategy bool
+
+	// keys are the keys of the map field being merged, whose comments are
+	// reported along with those of its value.  Set by the walker.
+	keys walk.Sources
+}
+
+// Report contains information collected while merging.
+type Report struct {
+	// UnchangedUpstream contains the paths of the fields which update left
+	// unchanged from origin.  Only populated if ReportUnchangedUpstream is set.
+	UnchangedUpstream []string
+
+	// Conflicts contains the fields which were changed in both dest and
+	// update.
+	Conflicts []Conflict
+
+	// ConflictGroups contains the Conflicts grouped by path pattern.  Only
+	// populated if GroupConflicts is set.
+	ConflictGroups []ConflictGroup
+
+	// Additions is a document containing only the fields and list elements
+	// which update added to dest.  Only populated if PreviewAdditions is set.
+	Additions *yaml.RNode
+
+	// Warnings contains non-fatal issues found while merging.
+	Warnings []string
+
+	// Drift measures how much dest has been customized from origin.  Only
+	// populated if MaxDrift or ReportDrift is set.
+	Drift Drift
+
+	// DestStyle is the style detected in dest.  Only populated if
+	// MatchDestStyle is set.
+	DestStyle DocumentStyle
+
+	// Decisions contains the source chosen for each field which wasn't
+	// merged field by field.  Only populated if RecordDecisions is set.
+	Decisions []Decision
+
+	// Changes maps the path of each field which isn't merged field by field
+	// to the effect of the merge on it.  Only populated if ClassifyChanges
+	// is set.
+	Changes map[string]ChangeKind
+
+	// ReversePatch restores dest when applied to the merged result.  Only
+	// populated if ReversePatch is set.
+	ReversePatch Patch
+
+	// Audit contains an entry for each field which isn't merged field by
+	// field.  Only populated if Audit is set.
+	Audit []AuditEntry
+
+	// MergeKeys contains the merge keys used for each associative list.
+	// Only populated if ReportMergeKeys is set.
+	MergeKeys []MergeKeys
+
+	// RuleMatches maps each configured rule which matched a field to the
+	// paths it matched.  Only populated if RecordRuleMatches is set.
+	RuleMatches map[string][]string
+
+	// TimedOut is true if the merge was stopped by the Timeout, and the
+	// result is only partially merged.
+	TimedOut bool
+
+	// Fingerprint is a hash of the fields the merge changed and how, so that
+	// resources changed identically can be detected across packages.  Only
+	// populated by a Registry with Fingerprints set.
+	Fingerprint string
+
+	// conflictPaths contains the paths of the fields recorded in Conflicts.
+	conflictPaths map[string]bool
+
+	// explanations maps each merged path to the reason its value was chosen.
+	explanations map[string]string
+
+	// audit is the state of the audit log.
+	audit *auditLog
+
+	// changes contains the fields which were changed or conflicted.  Only
+	// populated when diffing.
+	changes []change
+
+	// additions tracks the nodes update added to dest, for building the
+	// Additions document.
+	additions *additions
+}
+
+// Merge merges the changes between original and update into dest, and returns
+// the merged result along with a Report of the merge.
+func (m Visitor) Merge(dest, original, update *yaml.RNode) (*yaml.RNode, *Report, error) {
+	if err := m.handleDuplicateKeys(dest, original, update); err != nil {
+		return nil, nil, err
+	}
+	if m.SkipIdenticalInputs && identicalInputs(dest, original, update) {
+		return dest, &Report{}, nil
+	}
+
+	m.report = &Report{}
+	m.replay = replayIndex(m.Replay)
+	if m.Timeout > 0 {
+		m.deadline = time.Now().Add(m.Timeout)
+	}
+	if len(m.ServerDefaults) > 0 || len(m.ImmutableFields) > 0 {
+		m.kind = resourceKind(dest, original, update)
+	}
+	if m.explain || m.Audit != nil {
+		m.report.explanations = map[string]string{}
+	}
+	if m.Audit != nil {
+		m.report.audit = m.Audit.start()
+	}
+	if m.RecordRuleMatches {
+		m.report.RuleMatches = map[string][]string{}
+	}
+	if m.PreviewAdditions {
+		m.report.additions = &additions{
+			added:       map[*yaml.Node]bool{},
+			elementKeys: map[*yaml.Node][]string{},
+		}
+	}
+	var destNodes map[*yaml.Node]bool
+	if m.MatchDestStyle {
+		m.report.DestStyle = DetectStyle(dest)
+		destNodes = nodeSet(dest.YNode(), map[*yaml.Node]bool{})
+	}
+	var template string
+	if m.RestartAnnotation != "" {
+		// the merge modifies dest
+		var err error
+		if template, err = m.templateHash(dest); err != nil {
+			return nil, nil, err
+		}
+	}
+	var aliases map[*yaml.Node]yaml.Node
+	if m.CompareResolvedAnchors {
+		aliases = map[*yaml.Node]yaml.Node{}
+		expandAliases(dest.YNode(), aliases)
+		original, update = original.Copy(), update.Copy()
+		expandAliases(original.YNode(), nil)
+		expandAliases(update.YNode(), nil)
+	}
+	var sources lineage
+	if m.ValidateLineage {
+		sources = sourceLineage(dest, original, update)
+	}
+	result, err := walker{
+		visitor: m,
+		sources: []*yaml.RNode{dest, original, update},
+	}.walk()
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.ValidateLineage {
+		if err := sources.validate(result); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := restoreAliases(result, aliases); err != nil {
+		return nil, nil, err
+	}
+	if m.RestartAnnotation != "" {
+		if err := m.triggerRestart(result, template); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := m.updateLastApplied(result); err != nil {
+		return nil, nil, err
+	}
+	if m.MatchDestStyle && result != nil {
+		applyStringStyle(result.YNode(), destNodes, m.report.DestStyle.StringStyle)
+	}
+	m.checkDrift()
+	if m.GroupConflicts {
+		m.report.ConflictGroups = groupConflicts(m.report.Conflicts)
+	}
+	if m.PreviewAdditions {
+		m.report.Additions = m.report.additions.document(update)
+	}
+	return result, m.report, nil
+}
+
+// MergeStrings parses dest, original and update and merges them.
+func (m Visitor) MergeStrings(dest, original, update string) (string, *Report, error) {
+	if m.SkipIdenticalInputs && dest == original && dest == update {
+		// parse one of the inputs, so that invalid inputs are still an error
+		if _, err := yaml.Parse(dest); err != nil {
+			return "", nil, err
+		}
+		return m.TrailingNewline.apply(dest, dest), &Report{}, nil
+	}
+	srcOriginal, err := yaml.Parse(original)
+	if err != nil {
+		return "", nil, err
+	}
+	srcUpdated, err := yaml.Parse(update)
+	if err != nil {
+		return "", nil, err
+	}
+	d, err := yaml.Parse(dest)
+	if err != nil {
+		return "", nil, err
+	}
+	result, report, err := m.Merge(d, srcOriginal, srcUpdated)
+	if err != nil {
+		return "", nil, err
+	}
+	var s string
+	if m.MatchDestStyle && result != nil {
+		s, err = report.DestStyle.encode(result)
+	} else {
+		s, err = result.String()
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if m.AlignComments {
+		s = alignComments(s, commentColumns(dest))
+	}
+	s = m.TrailingNewline.apply(s, dest)
+	if m.VerifyOutput {
+		if err := verifyOutput(s, result); err != nil {
+			return "", nil, err
+		}
+	}
+	return s, report, nil
+}
+
+func (m Visitor) VisitMap(nodes walk.Sources, s *openapi.ResourceSchema, path []string) (*yaml.RNode, error) {
+	if m.clearedInUpdate(nodes) {
+		// explicitly cleared from update
+		return m.decide(path, walk.ClearNode, "deleted because update set it to null")
+	}
+	if m.initializesNullParent(nodes) {
+		return m.decide(path, yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode}),
+			"merged fields into a new map because it is null in dest")
+	}
+	if nodes.Dest().IsTaggedNull() {
+		// explicitly cleared from dest
+		return m.decide(path, walk.ClearNode, "deleted because dest set it to null")
+	}
+	if nodes.Dest() == nil && nodes.Updated() == nil {
+		// implicitly cleared missing from both dest and update
+		return m.decide(path, walk.ClearNode, "deleted because it is missing from dest and update")
+	}
+
+	if nodes.Dest() == nil {
+		// not cleared, but missing from the dest
+		// initialize a new value that can be recursively merged
+		return m.decide(path, yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode}),
+			"merged fields into a new map because it is missing from dest")
+	}
+
+	// recursively merge the dest with the original and updated
+	return m.decide(path, nodes.Dest(), "merged fields into the dest map")
+}
+
+// visitSubtree returns the value for the subtree at path, and true if the
+// subtree should not be walked.
+func (m Visitor) visitSubtree(nodes walk.Sources, path []string) (*yaml.RNode, bool, error) {
+	if node, found := m.replayDecision(nodes, path); found {
+		m.matchRule(ruleReplay, path)
+		node, err := m.decide(path, node, "replayed a recorded decision")
+		return node, true, err
+	}
+	if m.belowMaxDepth(path) {
+		m.matchRule(ruleMaxMergeDepth, path)
+		node, err := m.decide(path, nodes.Dest(), "kept dest because it is below the maximum merge depth")
+		return node, true, err
+	}
+	if node, found := m.status(nodes, path); found {
+		m.matchRule(ruleStatus, path)
+		node, err := m.decide(path, node, "took status using the status policy")
+		return node, true, err
+	}
+	if node, reason, found := m.metadataMap(nodes, path); found {
+		node, err := m.decide(path, node, reason)
+		return node, true, err
+	}
+	if m.convergedDeletion(nodes, path) {
+		node, err := m.decide(path, walk.ClearNode, "deleted because dest and update both removed it")
+		return node, true, err
+	}
+	if node, found, err := m.addedElement(nodes, path); found || err != nil {
+		return node, true, err
+	}
+	if node, found, err := m.immutableField(nodes, path); found || err != nil {
+		if err != nil {
+			return nil, true, err
+		}
+		node, err := m.decide(path, node, "kept dest because it is immutable")
+		return node, true, err
+	}
+	if node, found := m.serverField(nodes, path); found {
+		m.matchRule(ruleServerFields, path)
+		node, err := m.decide(path, node, "took the server-managed field using the server field policy")
+		return node, true, err
+	}
+	return nil, false, nil
+}
+
+func (m Visitor) visitAList(nodes walk.Sources, _ *openapi.ResourceSchema, path []string) (*yaml.RNode, error) {
+	if yaml.IsMissingOrNull(nodes.Updated()) && !yaml.IsMissingOrNull(nodes.Origin()) {
+		// implicitly cleared from update -- element was deleted
+		return m.decide(path, walk.ClearNode, "deleted because update removed it")
+	}
+	if yaml.IsMissingOrNull(nodes.Dest()) {
+		// not cleared, but missing from the dest
+		// initialize a new value that can be recursively merged
+		return m.decide(path, yaml.NewRNode(&yaml.Node{Kind: yaml.SequenceNode}),
+			"merged elements into a new list because it is missing from dest")
+	}
+
+	// recursively merge the dest with the original and updated
+	return m.decide(path, nodes.Dest(), "merged elements into the dest list")
+}
+
+func (m Visitor) VisitScalar(nodes walk.Sources, s *openapi.ResourceSchema, path []string) (*yaml.RNode, error) {
+	if err := m.recordDrift(nodes); err != nil {
+		return nil, err
+	}
+	if node, found := m.mergeSource(nodes, path); found {
+		return m.decide(path, node, "synced the merge-source annotation")
+	}
+	if m.clearedInUpdate(nodes) {
+		// explicitly cleared from update
+		return m.decide(path, nil, "deleted because update set it to null")
+	}
+	if nodes.Dest().IsTaggedNull() {
+		// explicitly cleared from dest
+		return m.decide(path, nil, "deleted because dest set it to null")
+	}
+	if node, reason, found := m.serverDefault(nodes, path); found {
+		return m.decide(path, node, reason)
+	}
+	if node, reason, found := m.appendText(nodes, path); found {
+		return m.decide(path, node, reason)
+	}
+	if yaml.IsMissingOrNull(nodes.Updated()) != yaml.IsMissingOrNull(nodes.Origin()) {
+		// value added or removed in update
+		if yaml.IsMissingOrNull(nodes.Updated()) {
+			return m.decide(path, nodes.Updated(), "deleted because update removed it")
+		}
+		if !yaml.IsMissingOrNull(nodes.Dest()) {
+			return m.visitAddedInBoth(nodes, path)
+		}
+		m.recordAddition(nodes)
+		m.checkPlaceholders(path, nodes.Updated())
+		return m.decide(path, nodes.Updated(), "took update because update added it")
+	}
+	if yaml.IsMissingOrNull(nodes.Updated()) && yaml.IsMissingOrNull(nodes.Origin()) {
+		// value added or removed in update
+		return m.decide(path, nodes.Dest(), "kept dest because it is missing from origin and update")
+	}
+
+	if m.adoptsBlockStyle(nodes) {
+		return m.decide(path, nodes.Updated(), "took update because it only changed the block style of origin")
+	}
+
+	values, err := m.getStrValues(nodes)
+	if err != nil {
+		return nil, err
+	}
+	if m.NormalizeNumbers {
+		if node, reason, found := m.precisionChange(nodes); found {
+			return m.decide(path, node, reason)
+		}
+		values = m.normalizeNumbers(nodes, values)
+	}
+	if m.IgnoreQuoting {
+		if values, err = m.unquotedValues(nodes, values); err != nil {
+			return nil, err
+		}
+		if values.Dest != "" && values.Dest == values.Update {
+			// keep the dest quoting
+			return m.decide(path, nodes.Dest(), "kept dest because dest==update ignoring quoting")
+		}
+	}
+
+	if (values.Dest == "" || values.Dest == values.Origin) && values.Origin != values.Update {
+		// if local is nil or is unchanged but there is new update
+		if values.Dest == "" {
+			return m.decide(path, nodes.Updated(), "took update because dest is missing and update!=origin")
+		}
+		return m.decide(path, nodes.Updated(), "took update because dest==origin and update!=origin")
+	}
+
+	if nodes.Updated().YNode().Value != nodes.Origin().YNode().Value {
+		// value changed in update
+		if values.Dest != values.Update {
+			strategy, err := m.recordConflict(path, nodes)
+			if err != nil {
+				return nil, err
+			}
+			if strategy == TakeDest {
+				return m.decide(path, nodes.Dest(), "kept dest because update!=origin and dest!=origin")
+			}
+		}
+		return m.decide(path, nodes.Updated(), "took update because update!=origin and dest!=origin")
+	}
+
+	// unchanged between origin and update, keep the dest
+	m.recordUnchangedUpstream(path)
+	return m.decide(path, nodes.Dest(), "kept dest because update==origin")
+}
+
+// visitKey merges the keys of a map field so that their comments are
+// merged like scalar values.
+func (m Visitor) visitKey(nodes walk.Sources) (*yaml.RNode, error) {
+	// keys don't have any schema or path of their own, and are not reported
+	m.report = nil
+	return m.VisitScalar(nodes, nil, nil)
+}
+
+func (m Visitor) visitNAList(nodes walk.Sources, path []string) (*yaml.RNode, error) {
+	if err := m.recordDrift(nodes); err != nil {
+		return nil, err
+	}
+	if m.isOrderedSet(nodes, path) && !m.clearedInUpdate(nodes) && !nodes.Dest().IsTaggedNull() {
+		return m.decide(path, orderedUnion(nodes, scalarID), "merged elements as an ordered set")
+	}
+	if m.isContentIdentity(path) && !m.clearedInUpdate(nodes) && !nodes.Dest().IsTaggedNull() {
+		return m.decide(path, orderedUnion(nodes, contentID), "merged elements identified by their content")
+	}
+	return m.visitAtomic(nodes, path)
+}
+
+// visitAtomic merges nodes as a whole, without merging their fields or
+// elements.
+func (m Visitor) visitAtomic(nodes walk.Sources, path []string) (*yaml.RNode, error) {
+	if m.clearedInUpdate(nodes) {
+		// explicitly cleared from update
+		return m.decide(path, walk.ClearNode, "deleted because update set it to null")
+	}
+	if nodes.Dest().IsTaggedNull() {
+		// explicitly cleared from dest
+		return m.decide(path, walk.ClearNode, "deleted because dest set it to null")
+	}
+
+	if yaml.IsMissingOrNull(nodes.Updated()) != yaml.IsMissingOrNull(nodes.Origin()) {
+		// value added or removed in update
+		if yaml.IsMissingOrNull(nodes.Updated()) {
+			return m.decide(path, nodes.Updated(), "deleted because update removed it")
+		}
+		if !yaml.IsMissingOrNull(nodes.Dest()) {
+			return m.visitAddedInBoth(nodes, path)
+		}
+		m.recordAddition(nodes)
+		m.checkPlaceholders(path, nodes.Updated())
+		return m.decide(path, nodes.Updated(), "took update because update added it")
+	}
+	if yaml.IsMissingOrNull(nodes.Updated()) && yaml.IsMissingOrNull(nodes.Origin()) {
+		// value not present in source or dest
+		return m.decide(path, nodes.Dest(), "kept dest because it is missing from origin and update")
+	}
+
+	// compare origin and update values to see if they have changed
+	values, err := m.getStrValues(nodes)
+	if err != nil {
+		return nil, err
+	}
+	if m.IgnoreQuoting {
+		if values, err = m.unquotedValues(nodes, values); err != nil {
+			return nil, err
+		}
+		if values.Dest != "" && values.Dest == values.Update {
+			// keep the dest quoting
+			return m.decide(path, nodes.Dest(), "kept dest because dest==update ignoring quoting")
+		}
+	}
+	if values.Update != values.Origin {
+		// value changed in update
+		if values.Dest != values.Origin && values.Dest != values.Update {
+			strategy, err := m.recordConflict(path, nodes)
+			if err != nil {
+				return nil, err
+			}
+			if strategy == TakeDest {
+				return m.decide(path, nodes.Dest(), "kept dest because update!=origin and dest!=origin")
+			}
+		}
+		return m.decide(path, nodes.Updated(), "took update because update!=origin")
+	}
+
+	// unchanged between origin and update, keep the dest
+	m.recordUnchangedUpstream(path)
+	return m.decide(path, nodes.Dest(), "kept dest because update==origin")
+}
+
+func (m Visitor) VisitList(nodes walk.Sources, s *openapi.ResourceSchema, kind walk.ListKind, path []string) (*yaml.RNode, error) {
+	if kind == walk.AssociativeList {
+		return m.visitAList(nodes, s, path)
+	}
+	// non-associative list
+	return m.visitNAList(nodes, path)
+}
+
+// clearedInUpdate returns true if update cleared the field by setting it
+// to null.
+func (m Visitor) clearedInUpdate(nodes walk.Sources) bool {
+	if !m.ExplicitNullDeletes {
+		return nodes.Updated().IsTaggedNull()
+	}
+	return isExplicitNull(nodes.Updated()) && !isExplicitNull(nodes.Origin())
+}
+
+// isExplicitNull returns true if node was written as null (e.g. `null`, `~`
+// or `!!null`) rather than being left empty.
+func isExplicitNull(node *yaml.RNode) bool {
+	if !node.IsTaggedNull() {
+		return false
+	}
+	return node.YNode().Value != "" || node.YNode().Style&yaml.TaggedStyle != 0
+}
+
+// recordUnchangedUpstream records that the field at path was not changed
+// between origin and update.
+func (m Visitor) recordUnchangedUpstream(path []string) {
+	if m.report == nil || !m.ReportUnchangedUpstream {
+		return
+	}
+	m.report.UnchangedUpstream = append(m.report.UnchangedUpstream, pathString(path))
+}
+
+func (m Visitor) getStrValues(nodes walk.Sources) (strValues, error) {
+	var uStr, oStr, dStr string
+	var err error
+	if nodes.Updated() != nil && nodes.Updated().YNode() != nil {
+		s := nodes.Updated().YNode().Style
+		defer func() {
+			nodes.Updated().YNode().Style = s
+		}()
+		nodes.Updated().YNode().Style = yaml.FlowStyle | yaml.SingleQuotedStyle
+		if m.CompareResolvedAnchors {
+			defer clearAnchor(nodes.Updated().YNode())()
+		}
+		uStr, err = nodes.Updated().String()
+		if err != nil {
+			return strValues{}, err
+		}
+	}
+	if nodes.Origin() != nil && nodes.Origin().YNode() != nil {
+		s := nodes.Origin().YNode().Style
+		defer func() {
+			nodes.Origin().YNode().Style = s
+		}()
+		nodes.Origin().YNode().Style = yaml.FlowStyle | yaml.SingleQuotedStyle
+		if m.CompareResolvedAnchors {
+			defer clearAnchor(nodes.Origin().YNode())()
+		}
+		oStr, err = nodes.Origin().String()
+		if err != nil {
+			return strValues{}, err
+		}
+	}
+	if nodes.Dest() != nil && nodes.Dest().YNode() != nil {
+		s := nodes.Dest().YNode().Style
+		defer func() {
+			nodes.Dest().YNode().Style = s
+		}()
+		nodes.Dest().YNode().Style = yaml.FlowStyle | yaml.SingleQuotedStyle
+		if m.CompareResolvedAnchors {
+			defer clearAnchor(nodes.Dest().YNode())()
+		}
+		dStr, err = nodes.Dest().String()
+		if err != nil {
+			return strValues{}, err
+		}
+	}
+
+	return strValues{Origin: oStr, Update: uStr, Dest: dStr}, nil
+}
+
+type strValues struct {
+	Origin string
+	Update string
+	Dest   string
+}
@@ -0,0 +1,392 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge3_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/merge3"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestVisitor_ReportUnchangedUpstream(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a, b]
+      - name: sidecar
+        image: sidecar:1.0
+`
+	update := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, b]
+      - name: sidecar
+        image: sidecar:1.0
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    team: local
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [a, b]
+      - name: sidecar
+        image: sidecar:2.0
+`
+	expected := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    team: local
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [a, b]
+      - name: sidecar
+        image: sidecar:2.0
+`
+
+	actual, report, err := Visitor{ReportUnchangedUpstream: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual))
+	assert.ElementsMatch(t, []string{
+		"apiVersion",
+		"kind",
+		"metadata.name",
+		"spec.template.spec.containers[name=nginx].args",
+		"spec.template.spec.containers[name=nginx].name",
+		"spec.template.spec.containers[name=sidecar].image",
+		"spec.template.spec.containers[name=sidecar].name",
+	}, report.UnchangedUpstream)
+
+	// the report is only populated when requested
+	_, report, err = Visitor{}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, report.UnchangedUpstream)
+}
+
+func TestVisitor_StrategicMergePatch(t *testing.T) {
+	origin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [--port, "80"]
+        ports:
+        - containerPort: 80
+          protocol: TCP
+      - name: logger
+        image: logger:1.0
+      tolerations:
+      - key: a
+        operator: Exists
+`
+	update := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.8
+        args: [--port, "8080"]
+        ports:
+        - containerPort: 80
+          protocol: TCP
+          name: http
+        - containerPort: 443
+          protocol: TCP
+      tolerations:
+      - key: b
+        operator: Exists
+`
+	local := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.7
+        args: [--port, "80", --verbose]
+        env:
+        - name: FOO
+          value: bar
+        ports:
+        - containerPort: 80
+          protocol: TCP
+      - name: logger
+        image: logger:1.0
+      - name: sidecar
+        image: sidecar:1.0
+      tolerations:
+      - key: a
+        operator: Exists
+      - key: c
+        operator: Exists
+`
+
+	// compute the result using a strategic merge patch
+	toJSON := func(s string) []byte {
+		b, err := yaml.MustParse(s).MarshalJSON()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return b
+	}
+	lookup, err := strategicpatch.NewPatchMetaFromStruct(appsv1.Deployment{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(
+		toJSON(origin), toJSON(update), toJSON(local), lookup, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	expected, err := strategicpatch.StrategicMergePatch(toJSON(local), patch, appsv1.Deployment{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	actual, _, err := Visitor{StrategicMergePatch: true, InferAssociativeLists: true}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, string(expected), string(toJSON(actual)))
+}
+
+func TestVisitor_StrategicMergePatch_noSchema(t *testing.T) {
+	origin := `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+`
+	update := `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+`
+	local := `
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 1
+  - name: b
+    value: 1
+`
+
+	// lists without a schema are inferred to be associative by default
+	actual, _, err := Visitor{InferAssociativeLists: true}.MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(`
+apiVersion: example.com/v1
+kind: Foo
+spec:
+  items:
+  - name: a
+    value: 2
+  - name: b
+    value: 1
+`), strings.TrimSpace(actual))
+
+	// strategic merge patch replaces lists without a merge strategy
+	actual, _, err = Visitor{InferAssociativeLists: true, StrategicMergePatch: true}.
+		MergeStrings(local, origin, update)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, strings.TrimSpace(update), strings.TrimSpace(actual))
+}
+
+func TestVisitor_ExplicitNullDeletes(t *testing.T) {
+	var testCases = []struct {
+		description         string
+		origin              string
+		update              string
+		local               string
+		expected            string
+		explicitNullDeletes bool
+	}{
+		{
+			description: `explicit null in update deletes a map from dest`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec: null`,
+			local: `
+kind: Foo
+spec:
+  a: b`,
+			expected: `
+kind: Foo`,
+			explicitNullDeletes: true,
+		},
+		{
+			description: `explicit null tag in update deletes a scalar from dest`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+replicas: !!null`,
+			local: `
+kind: Foo
+replicas: 3`,
+			expected: `
+kind: Foo`,
+			explicitNullDeletes: true,
+		},
+		{
+			description: `implicit null in update keeps dest`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec:`,
+			local: `
+kind: Foo
+spec:
+  a: b`,
+			expected: `
+kind: Foo
+spec:
+  a: b`,
+			explicitNullDeletes: true,
+		},
+		{
+			description: `implicit null in update deletes dest without the option`,
+			origin: `
+kind: Foo`,
+			update: `
+kind: Foo
+spec:`,
+			local: `
+kind: Foo
+spec:
+  a: b`,
+			expected: `
+kind: Foo`,
+		},
+		{
+			description: `explicit null unchanged from origin keeps dest`,
+			origin: `
+kind: Foo
+spec: ~`,
+			update: `
+kind: Foo
+spec: ~`,
+			local: `
+kind: Foo
+spec:
+  a: b`,
+			expected: `
+kind: Foo
+spec:
+  a: b`,
+			explicitNullDeletes: true,
+		},
+		{
+			description: `implicit null removing a value from origin deletes dest`,
+			origin: `
+kind: Foo
+replicas: 3`,
+			update: `
+kind: Foo
+replicas:`,
+			local: `
+kind: Foo
+replicas: 3`,
+			expected: `
+kind: Foo`,
+			explicitNullDeletes: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			actual, _, err := Visitor{ExplicitNullDeletes: tc.explicitNullDeletes}.
+				MergeStrings(tc.local, tc.origin, tc.update)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(actual))
+		})
+	}
+}
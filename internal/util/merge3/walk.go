@@ -0,0 +1,831 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package merge3
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"sigs.k8s.io/kustomize/kyaml/fieldmeta"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/schema"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
+)
+
+// walker walks the dest, origin and updated Sources and invokes the Visitor
+// for each node.  It is a fork of the kyaml walk.Walker which additionally
+// keeps track of the path to each visited node, so that the Visitor can
+// report on the fields it merged.
+type walker struct {
+	visitor Visitor
+
+	schema *openapi.ResourceSchema
+
+	// sources are the nodes to walk.  All source fields and associative list
+	// elements will be visited.
+	sources walk.Sources
+
+	// path is the path to the current source nodes.  Associative list elements
+	// are identified by their merge key, e.g. `containers[name=nginx]`.
+	path []string
+
+	// atomic is true if the sources are replaced as a whole rather than
+	// merged, as declared by an inline hint.
+	atomic bool
+
+	// hint is true if the schema is declared by an inline merge key hint.
+	hint bool
+
+	// readOnly is true if dest marked the sources with a `# readonly`
+	// comment.
+	readOnly bool
+}
+
+// kind returns the kind of the first non-null node in sources.
+func (l walker) kind() yaml.Kind {
+	for _, s := range l.sources {
+		if !yaml.IsMissingOrNull(s) {
+			return s.YNode().Kind
+		}
+	}
+	return 0
+}
+
+// walk recursively traverses every item in the sources and merges them
+// using the visitor.
+func (l walker) walk() (*yaml.RNode, error) {
+	sources, err := l.migrateSequences()
+	if err != nil {
+		return nil, err
+	}
+	l.sources = sources
+	l.schema = l.getSchema()
+
+	if l.visitor.timedOut(l.path) {
+		// leave the rest of dest unmerged
+		return l.sources.Dest(), nil
+	}
+
+	if l.readOnly {
+		node, err := l.visitor.visitReadOnly(l.sources, l.path)
+		return l.visitor.recordDecision(l.path, l.sources, node, err)
+	}
+
+	// some subtrees are taken as a whole rather than merged field by field
+	if node, found, err := l.visitor.visitSubtree(l.sources, l.path); found || err != nil {
+		return l.visitor.recordDecision(l.path, l.sources, node, err)
+	}
+	if l.atomic {
+		if err := l.visitor.recordDrift(l.sources); err != nil {
+			return nil, err
+		}
+		node, err := l.visitor.visitAtomic(l.sources, l.path)
+		return l.visitor.recordDecision(l.path, l.sources, node, err)
+	}
+
+	switch l.kind() {
+	case yaml.MappingNode:
+		if err := yaml.ErrorIfAnyInvalidAndNonNull(yaml.MappingNode, l.sources...); err != nil {
+			return nil, err
+		}
+		return l.keepCollectionTag(l.walkMap())
+	case yaml.SequenceNode:
+		if err := yaml.ErrorIfAnyInvalidAndNonNull(yaml.SequenceNode, l.sources...); err != nil {
+			return nil, err
+		}
+		return l.keepCollectionTag(l.walkSequence())
+	case yaml.ScalarNode:
+		if err := yaml.ErrorIfAnyInvalidAndNonNull(yaml.ScalarNode, l.sources...); err != nil {
+			return nil, err
+		}
+		node, err := l.walkScalar()
+		node, err = l.visitor.recordDecision(l.path, l.sources, node, err)
+		return l.visitor.transform(l.path, node, err)
+	case 0:
+		// walk empty nodes as maps
+		return l.walkMap()
+	default:
+		return nil, nil
+	}
+}
+
+// walkSequence merges the sources as an associative or non-associative
+// list, handling null elements according to the NullElements policy.
+func (l walker) walkSequence() (*yaml.RNode, error) {
+	sources := l.sources
+	withoutNulls, nulls := withoutNullElements(l.sources)
+
+	// strategic merge patch only merges lists which have a merge strategy
+	// in the schema
+	infer := l.visitor.InferAssociativeLists && !l.visitor.StrategicMergePatch
+	identity, key := l.visitor.listIdentity(l.path)
+	if identity != ListIdentityDefault {
+		l.visitor.matchRule("ListIdentities: "+key, l.path)
+	}
+	associative := identity != ListIdentityContent &&
+		schema.IsAssociative(l.schema, withoutNulls, infer || identity == ListIdentityMergeKey)
+	if associative || l.visitor.NullElements != NullElementsAsValues {
+		l.sources = withoutNulls
+	}
+
+	var node *yaml.RNode
+	var err error
+	if associative {
+		node, err = l.walkAssociativeSequence()
+	} else {
+		node, err = l.walkNonAssociativeSequence()
+		node, err = l.visitor.recordDecision(l.path, l.sources, node, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.visitor.NullElements == NullElementsByPosition {
+		insertNullElements(node, nulls, sources, l.visitor.PreserveNullTags)
+	}
+	return node, nil
+}
+
+// child returns a walker for the given sources nested under the current path.
+func (l walker) child(sources walk.Sources, s *openapi.ResourceSchema, segment string) walker {
+	path := make([]string, len(l.path), len(l.path)+1)
+	copy(path, l.path)
+	v := l.visitor
+	v.keys = nil
+	return walker{
+		visitor: v,
+		schema:  s,
+		sources: sources,
+		path:    append(path, segment),
+	}
+}
+
+func (l walker) getSchema() *openapi.ResourceSchema {
+	for i := range l.sources {
+		r := l.sources[i]
+		if yaml.IsMissingOrNull(r) {
+			continue
+		}
+
+		fm := fieldmeta.FieldMeta{}
+		if err := fm.Read(r); err == nil && !fm.IsEmpty() {
+			// per-field schema, this is fine
+			if fm.Schema.Ref.String() != "" {
+				// resolve the reference
+				s, err := openapi.Resolve(&fm.Schema.Ref, openapi.Schema())
+				if err == nil && s != nil {
+					fm.Schema = *s
+				}
+			}
+			return &openapi.ResourceSchema{Schema: &fm.Schema}
+		}
+	}
+
+	if l.schema != nil {
+		return l.schema
+	}
+	for i := range l.sources {
+		r := l.sources[i]
+		if yaml.IsMissingOrNull(r) {
+			continue
+		}
+
+		m, _ := r.GetMeta()
+		if m.Kind == "" || m.APIVersion == "" {
+			continue
+		}
+
+		s := openapi.SchemaForResourceType(yaml.TypeMeta{Kind: m.Kind, APIVersion: m.APIVersion})
+		if s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// setDest sets the destination source node
+func (l walker) setDest(node *yaml.RNode, err error) (*yaml.RNode, error) {
+	if err != nil {
+		return nil, err
+	}
+	l.sources[walk.DestIndex] = node
+	return node, nil
+}
+
+func (l walker) walkScalar() (*yaml.RNode, error) {
+	node, err := l.visitor.VisitScalar(l.sources, l.schema, l.path)
+	if err != nil {
+		return nil, err
+	}
+	node = l.visitor.keepChomping(l.sources, node)
+	return l.visitor.writeCanonicalFloat(node), nil
+}
+
+func (l walker) walkNonAssociativeSequence() (*yaml.RNode, error) {
+	return l.visitor.VisitList(l.sources, l.schema, walk.NonAssociateList, l.path)
+}
+
+// walkMap returns the value of VisitMap
+//
+// - call VisitMap
+// - set the return value on dest
+// - walk each source field
+// - set each source field value on dest
+func (l walker) walkMap() (*yaml.RNode, error) {
+	if err := l.checkTopLevelKeys(); err != nil {
+		return nil, err
+	}
+
+	swaps := l.scalarFields()
+
+	// get the new map value
+	sources := append(walk.Sources{}, l.sources...)
+	dest, err := l.setDest(l.visitor.VisitMap(l.sources, l.schema, l.path))
+	if dest == nil || err != nil {
+		// the map is cleared as a whole
+		return l.visitor.recordDecision(l.path, sources, nil, err)
+	}
+
+	// recursively set the field values on the map
+	for _, key := range l.fieldNames() {
+		// visit the map keys as if they were scalars so that comments
+		// are copied
+		var keys walk.Sources
+		for i := range l.sources {
+			if l.sources[i] == nil {
+				keys = append(keys, nil)
+				continue
+			}
+			field := l.sources[i].Field(key)
+			if field == nil || yaml.IsMissingOrNull(field.Key) {
+				keys = append(keys, nil)
+				continue
+			}
+			keys = append(keys, field.Key)
+		}
+		res, err := l.visitor.visitKey(keys)
+		if err != nil {
+			return nil, err
+		}
+
+		var s *openapi.ResourceSchema
+		if l.schema != nil {
+			s = l.schema.Field(key)
+		}
+		fv, commentSch := l.fieldValue(key)
+		if commentSch != nil {
+			s = commentSch
+		}
+		h := parseHints(keys)
+		hintSch := h.schema()
+		if hintSch != nil {
+			s = hintSch
+		}
+		child := l.child(fv, s, key)
+		child.visitor.keys = keys
+		child.atomic = h.atomic
+		child.hint = hintSch != nil
+		child.readOnly = l.visitor.readOnly(keys, fv)
+		for _, rule := range h.rules() {
+			l.visitor.matchRule(rule, child.path)
+		}
+		val, err := child.walk()
+		if err != nil {
+			return nil, err
+		}
+
+		// transfer the comments of res to dest node
+		var comments yaml.Comments
+		if !yaml.IsMissingOrNull(res) {
+			comments = yaml.Comments{
+				LineComment: res.YNode().LineComment,
+				HeadComment: l.visitor.description(keys, res.YNode().HeadComment),
+				FootComment: res.YNode().FootComment,
+			}
+			if len(keys) > 0 && !yaml.IsMissingOrNull(keys[walk.DestIndex]) {
+				destKey := keys[walk.DestIndex].YNode()
+				comments.HeadComment = l.visitor.protectComment(destKey.HeadComment, comments.HeadComment)
+				comments.LineComment = l.visitor.protectComment(destKey.LineComment, comments.LineComment)
+				comments.FootComment = l.visitor.protectComment(destKey.FootComment, comments.FootComment)
+				keys[walk.DestIndex].YNode().HeadComment = comments.HeadComment
+				keys[walk.DestIndex].YNode().LineComment = comments.LineComment
+				keys[walk.DestIndex].YNode().FootComment = comments.FootComment
+			}
+		}
+		if len(fv) > walk.DestIndex {
+			l.visitor.protectComments(fv[walk.DestIndex], val)
+		}
+
+		// this handles empty and non-empty values
+		if err := setField(dest, key, comments, val, l.visitor.keepsStyle(fv, val)); err != nil {
+			return nil, err
+		}
+	}
+	l.checkSwaps(swaps, dest)
+
+	return dest, nil
+}
+
+// setField sets the field key on the map dest to val, or clears it if val is
+// nil or null.  The style of an existing field is kept unless keepStyle is
+// set.
+func setField(dest *yaml.RNode, key string, comments yaml.Comments, val *yaml.RNode, keepStyle bool) error {
+	if key != "" {
+		var style yaml.Style
+		if val != nil {
+			style = val.YNode().Style
+		}
+		field, err := dest.Pipe(yaml.FieldSetter{Name: key, Comments: comments, Value: val})
+		if err == nil && keepStyle && field != nil {
+			field.YNode().Style = style
+		}
+		return err
+	}
+
+	// FieldSetter sets the value of dest itself when the name is empty, so
+	// set fields with an empty-string key directly
+	if val == nil || val.IsTaggedNull() {
+		_, err := dest.Pipe(yaml.Clear(key))
+		return err
+	}
+	content := dest.YNode().Content
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i].Value == key {
+			if !keepStyle {
+				val.YNode().Style = content[i+1].Style
+			}
+			content[i+1] = val.YNode()
+			return nil
+		}
+	}
+	dest.YNode().Content = append(content,
+		&yaml.Node{
+			Kind:        yaml.ScalarNode,
+			Value:       key,
+			Style:       yaml.DoubleQuotedStyle,
+			HeadComment: comments.HeadComment,
+			LineComment: comments.LineComment,
+			FootComment: comments.FootComment,
+		},
+		val.YNode())
+	return nil
+}
+
+// valueIfPresent returns node.Value if node is non-nil, otherwise returns nil
+func (l walker) valueIfPresent(node *yaml.MapNode) (*yaml.RNode, *openapi.ResourceSchema) {
+	if node == nil {
+		return nil, nil
+	}
+
+	// parse the schema for the field if present
+	var s *openapi.ResourceSchema
+	fm := fieldmeta.FieldMeta{}
+	var err error
+	// check the value for a schema
+	if err = fm.Read(node.Value); err == nil {
+		s = &openapi.ResourceSchema{Schema: &fm.Schema}
+		if fm.Schema.Ref.String() != "" {
+			r, err := openapi.Resolve(&fm.Schema.Ref, openapi.Schema())
+			if err == nil && r != nil {
+				s.Schema = r
+			}
+		}
+	}
+	// check the key for a schema -- this will be used
+	// when the value is a Sequence (comments are attached)
+	// to the key
+	if fm.IsEmpty() {
+		if err = fm.Read(node.Key); err == nil {
+			s = &openapi.ResourceSchema{Schema: &fm.Schema}
+		}
+		if fm.Schema.Ref.String() != "" {
+			r, err := openapi.Resolve(&fm.Schema.Ref, openapi.Schema())
+			if err == nil && r != nil {
+				s.Schema = r
+			}
+		}
+	}
+	return node.Value, s
+}
+
+// fieldNames returns a sorted slice containing the names of all fields that appear in any of
+// the sources, or the names in source order if KeepAddedKeyOrder is set
+func (l walker) fieldNames() []string {
+	if l.visitor.KeepAddedKeyOrder {
+		return l.orderedFieldNames()
+	}
+	fields := sets.String{}
+	for _, s := range l.sources {
+		if s == nil {
+			continue
+		}
+		// don't check error, we know this is a mapping node
+		sFields, _ := s.Fields()
+		fields.Insert(sFields...)
+	}
+	result := fields.List()
+	sort.Strings(result)
+	return result
+}
+
+// fieldValue returns a slice containing each source's value for fieldName
+func (l walker) fieldValue(fieldName string) ([]*yaml.RNode, *openapi.ResourceSchema) {
+	var fields []*yaml.RNode
+	var sch *openapi.ResourceSchema
+	for i := range l.sources {
+		if l.sources[i] == nil {
+			fields = append(fields, nil)
+			continue
+		}
+		field := l.sources[i].Field(fieldName)
+		f, s := l.valueIfPresent(field)
+		fields = append(fields, f)
+		if sch == nil && !s.IsMissingOrNull() {
+			sch = s
+		}
+	}
+	return fields, sch
+}
+
+// appendListNode will append the nodes from src to dst and return dst.
+// src and dst should be both sequence node. key is used to call ElementSetter.
+// ElementSetter will use key-value pair to find and set the element in sequence
+// node.
+func appendListNode(dst, src *yaml.RNode, keys []string) (*yaml.RNode, error) {
+	var err error
+	for _, elem := range src.Content() {
+		// If key is empty, we know this is a scalar value and we can directly set the
+		// node
+		if keys[0] == "" {
+			_, err = dst.Pipe(yaml.ElementSetter{
+				Element: elem,
+				Keys:    []string{""},
+				Values:  []string{elem.Value},
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// we need to get the value for key so that we can find the element to set
+		// in sequence.
+		v := []string{}
+		for _, key := range keys {
+			tmpNode := yaml.NewRNode(elem)
+			valueNode, err := tmpNode.Pipe(yaml.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			if valueNode.IsNil() {
+				// no key found, directly append to dst
+				err = dst.PipeE(yaml.Append(elem))
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v = append(v, valueNode.YNode().Value)
+		}
+
+		// We use the key and value from elem to find the corresponding element in dst.
+		// Then we will use ElementSetter to replace the element with elem.
+		_, err = dst.Pipe(yaml.ElementSetter{
+			Element: elem,
+			Keys:    keys,
+			Values:  v,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// validateKeys returns a list of valid key-value pairs
+// if secondary merge key values are missing, use only the available merge keys
+func validateKeys(valuesList [][]string, values []string, keys []string) ([]string, []string) {
+	validKeys := make([]string, 0)
+	validValues := make([]string, 0)
+	validKeySet := sets.String{}
+	for _, values := range valuesList {
+		for i, v := range values {
+			if v != "" {
+				validKeySet.Insert(keys[i])
+			}
+		}
+	}
+	if validKeySet.Len() == 0 { // if values missing, fall back to primary keys
+		return keys, values
+	}
+	for _, k := range keys {
+		if validKeySet.Has(k) {
+			validKeys = append(validKeys, k)
+		}
+	}
+	for i, v := range values {
+		if v != "" || validKeySet.Has(keys[i]) {
+			validValues = append(validValues, v)
+		}
+	}
+	return validKeys, validValues
+}
+
+// elementSegment returns the path segment identifying the associative list
+// element with the given merge key values.
+func elementSegment(keys, values []string) string {
+	var parts []string
+	for i := range keys {
+		if keys[i] == "" {
+			// primitive list -- the value is the identity
+			parts = append(parts, values[i])
+			continue
+		}
+		parts = append(parts, keys[i]+"="+values[i])
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// pathString returns the string representation of path, e.g.
+// `spec.containers[name=nginx].image`.  Fields with an empty-string key are
+// written as `""`, e.g. `data.""`.
+func pathString(path []string) string {
+	var b strings.Builder
+	for i, segment := range path {
+		if i > 0 && !strings.HasPrefix(segment, "[") {
+			b.WriteString(".")
+		}
+		if segment == "" {
+			segment = `""`
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// setAssociativeSequenceElements recursively set the elements in the list
+func (l walker) setAssociativeSequenceElements(valuesList [][]string, keys []string, dest *yaml.RNode) (*yaml.RNode, error) {
+	// itemsToBeAdded contains the items that will be added to dest
+	itemsToBeAdded := yaml.NewListRNode()
+	var s *openapi.ResourceSchema
+	if l.schema != nil {
+		s = l.schema.Elements()
+	}
+
+	// each element in valuesList is a list of values corresponding to the keys
+	// for example, for the following yaml:
+	//        - containerPort: 8080
+	//          protocol: UDP
+	//        - containerPort: 8080
+	//          protocol: TCP
+	// `keys` would be [containerPort, protocol]
+	// and `valuesList` would be [ [8080, UDP], [8080, TCP] ]
+	for _, values := range valuesList {
+		if len(values) == 0 {
+			continue
+		}
+
+		validKeys, validValues := validateKeys(valuesList, values, keys)
+		elements := l.elementValueList(validKeys, validValues)
+		l.visitor.recordElementKeys(elements, validKeys)
+		child := l.child(elements, s, elementSegment(validKeys, validValues))
+		child.readOnly = l.visitor.readOnlyElement(elements)
+		val, err := child.walk()
+		if err != nil {
+			return nil, err
+		}
+		if child.readOnly {
+			val = keepReadOnlyComment(val)
+		}
+
+		exit := false
+		for i, key := range validKeys {
+			// delete the node from **dest** if it's null or empty
+			if yaml.IsMissingOrNull(val) || yaml.IsEmptyMap(val) {
+				_, err = dest.Pipe(yaml.ElementSetter{
+					Keys:   validKeys,
+					Values: validValues,
+				})
+				if err != nil {
+					return nil, err
+				}
+				exit = true
+			} else if val.Field(key) == nil {
+				// make sure the key is set on the field
+				_, err = val.Pipe(yaml.SetField(key, yaml.NewScalarRNode(validValues[i])))
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if exit {
+			continue
+		}
+
+		// Add the val to the sequence. val will replace the item in the sequence if
+		// there is an item that matches all key-value pairs. Otherwise val will be appended
+		// the the sequence.
+		_, err = itemsToBeAdded.Pipe(yaml.ElementSetter{
+			Element: val.YNode(),
+			Keys:    validKeys,
+			Values:  values,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if len(valuesList) > 0 {
+		validKeys, _ := validateKeys(valuesList, valuesList[0], keys)
+		// append the items
+		dest, err = appendListNode(dest, itemsToBeAdded, validKeys)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// sequence is empty
+	if yaml.IsMissingOrNull(dest) {
+		return nil, nil
+	}
+	return dest, nil
+}
+
+func (l walker) walkAssociativeSequence() (*yaml.RNode, error) {
+	initial := append([]*yaml.Node{}, l.sources.Dest().Content()...)
+
+	// may require initializing the dest node
+	sources := append(walk.Sources{}, l.sources...)
+	dest, err := l.setDest(l.visitor.VisitList(l.sources, l.schema, walk.AssociativeList, l.path))
+	if dest == nil || err != nil {
+		// the list is cleared as a whole
+		return l.visitor.recordDecision(l.path, sources, nil, err)
+	}
+
+	// get the merge key(s) from schema
+	var strategy string
+	var keys []string
+	source := MergeKeySchema
+	if l.hint {
+		source = MergeKeyHint
+	}
+	switch {
+	case l.schema != nil && l.visitor.StrategicMergePatch:
+		// strategic merge patch identifies elements by the patchMergeKey
+		// alone, and ignores the list-map-keys
+		var key string
+		strategy, key = l.schema.PatchStrategyAndKey()
+		if key != "" {
+			keys = append(keys, key)
+		}
+	case l.schema != nil:
+		strategy, keys = l.schema.PatchStrategyAndKeyList()
+	}
+	if strategy == "" && len(keys) == 0 { // neither strategy nor keys present in the schema -- infer the key
+		// find the list of elements we need to recursively walk
+		key, err := l.elementKey()
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+		source = MergeKeyInferred
+	}
+	l.visitor.recordMergeKeys(l.path, keys, source)
+
+	// elements without the merge key are merged separately
+	var keyless keylessElements
+	if len(keys) > 0 && l.visitor.KeylessElements != KeylessElementsMerge {
+		var found bool
+		l.sources, keyless, found = splitKeylessElements(l.sources, keys)
+		if found && l.visitor.KeylessElements == KeylessElementsError {
+			return nil, missingMergeKey(l.path, keys)
+		}
+	}
+
+	l.sources = l.normalizeMergeKeys(keys)
+
+	// non-primitive associative list -- merge the elements
+	values := l.elementValues(keys)
+	if len(values) == 0 && len(keys) == 0 {
+		// primitive associative list -- merge the values
+		values, keys = l.elementPrimitiveValues(), []string{""}
+	}
+	dest, err = l.setAssociativeSequenceElements(values, keys, dest)
+	if err != nil {
+		return nil, err
+	}
+	keylessResult, err := l.mergeKeylessElements(keyless)
+	if err != nil {
+		return nil, err
+	}
+	if dest == nil {
+		if len(keylessResult) == 0 {
+			return nil, nil
+		}
+		dest = yaml.NewListRNode()
+	}
+	if err := l.visitor.checkUniqueKeys(dest, l.path, keys); err != nil {
+		return nil, err
+	}
+	if err := l.visitor.checkListGrowth(l.path, len(initial), len(dest.Content())); err != nil {
+		return nil, err
+	}
+	if err := l.visitor.checkAdditionsPerKey(l.path, keys, initial, dest); err != nil {
+		return nil, err
+	}
+	l.visitor.sortElements(dest, l.path, keys)
+	dest.YNode().Content = append(dest.YNode().Content, keylessResult...)
+	return dest, nil
+}
+
+// elementKey returns the merge key to use for the associative list
+func (l walker) elementKey() (string, error) {
+	var key string
+	for i := range l.sources {
+		if l.sources[i] != nil && len(l.sources[i].Content()) > 0 {
+			newKey := l.sources[i].GetAssociativeKey()
+			if key != "" && key != newKey {
+				return "", errors.Errorf(
+					"conflicting merge keys [%s,%s] for field %s",
+					key, newKey, strings.Join(l.path, "."))
+			}
+			key = newKey
+		}
+	}
+	if key == "" {
+		return "", errors.Errorf("no merge key found for field %s",
+			strings.Join(l.path, "."))
+	}
+	return key, nil
+}
+
+// elementValues returns a slice containing all values for the field across all elements
+// from all sources.
+// Return value slice is ordered using the original ordering from the elements, where
+// elements missing from earlier sources appear later.
+func (l walker) elementValues(keys []string) [][]string {
+	// use slice to to keep elements in the original order
+	var returnValues [][]string
+	var seen sets.StringList
+	for i := range l.sources {
+		src := l.sources[i]
+		if src == nil {
+			continue
+		}
+
+		// add the value of the field for each element
+		// don't check error, we know this is a list node
+		values, _ := src.ElementValuesList(keys)
+		for _, s := range values {
+			if len(s) == 0 || seen.Has(s) {
+				continue
+			}
+			returnValues = append(returnValues, s)
+			seen = seen.Insert(s)
+		}
+	}
+	return returnValues
+}
+
+// elementPrimitiveValues returns the primitive values in an associative list -- eg. finalizers
+func (l walker) elementPrimitiveValues() [][]string {
+	// use slice to to keep elements in the original order
+	var returnValues [][]string
+	seen := sets.String{}
+	for i := range l.sources {
+		src := l.sources[i]
+		if src == nil {
+			continue
+		}
+
+		// add the value of the field for each element
+		// don't check error, we know this is a list node
+		for _, item := range src.YNode().Content {
+			if seen.Has(item.Value) {
+				continue
+			}
+			returnValues = append(returnValues, []string{item.Value})
+			seen.Insert(item.Value)
+		}
+	}
+	return returnValues
+}
+
+// elementValueList returns a slice containing each source's element matching
+// the keys and values
+func (l walker) elementValueList(keys []string, values []string) []*yaml.RNode {
+	var fields []*yaml.RNode
+	for i := range l.sources {
+		if l.sources[i] == nil {
+			fields = append(fields, nil)
+			continue
+		}
+		fields = append(fields, l.sources[i].ElementList(keys, values))
+	}
+	return fields
+}